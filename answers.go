@@ -0,0 +1,309 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+	"github.com/dlclark/regexp2"
+)
+
+// Question kinds
+const (
+	KindChoice = "choice"
+	KindText   = "text"
+	KindNumber = "number"
+	KindRank   = "rank"
+)
+
+// Match rules of accepted answers
+const (
+	MatchExact   = "exact"
+	MatchCI      = "ci"
+	MatchRegex   = "regex"
+	MatchNumeric = "numeric"
+	MatchPercent = "percent"
+)
+
+// Regex answers are written by question authors, so their size and the time
+// spent matching them are bounded.
+const (
+	maxRegexLength = 200
+	regexTimeout   = 100 * time.Millisecond
+)
+
+// compileRegex compiles a regex answer with a match timeout.
+func compileRegex(pattern string) (*regexp2.Regexp, error) {
+	re, err := regexp2.Compile(pattern, regexp2.None)
+	if err != nil {
+		return nil, err
+	}
+	re.MatchTimeout = regexTimeout
+	return re, nil
+}
+
+// AcceptedAnswer is one answer that counts as correct on a free-text question.
+type AcceptedAnswer struct {
+	Position  int     `db:"position"`
+	Value     string  `db:"value"`
+	Match     string  `db:"match_rule"`
+	Tolerance float64 `db:"tolerance"`
+}
+
+// validate checks that the answer can be matched against at all.
+func (a *AcceptedAnswer) validate() error {
+	switch a.Match {
+	case MatchExact, MatchCI:
+	case MatchRegex:
+		if utf8.RuneCountInString(a.Value) > maxRegexLength {
+			return fmt.Errorf("regex %q is longer than %d characters", a.Value, maxRegexLength)
+		}
+		if _, err := compileRegex(a.Value); err != nil {
+			return fmt.Errorf("invalid regex %q: %w", a.Value, err)
+		}
+	case MatchNumeric, MatchPercent:
+		if _, err := parseNumber(a.Value); err != nil {
+			return fmt.Errorf("invalid number %q", a.Value)
+		}
+		if a.Tolerance < 0 || math.IsInf(a.Tolerance, 0) || math.IsNaN(a.Tolerance) {
+			return fmt.Errorf("invalid tolerance %g", a.Tolerance)
+		}
+	default:
+		return fmt.Errorf("unknown match rule %q", a.Match)
+	}
+	return nil
+}
+
+// matches reports whether the given answer is accepted. Regexes match anywhere in
+// the answer unless anchored.
+func (a *AcceptedAnswer) matches(value string) bool {
+	value = strings.TrimSpace(value)
+	switch a.Match {
+	case MatchExact:
+		return value == a.Value
+	case MatchCI:
+		return strings.EqualFold(value, a.Value)
+	case MatchRegex:
+		re, err := compileRegex(a.Value)
+		if err != nil {
+			return false
+		}
+		ok, err := re.MatchString(value)
+		return err == nil && ok
+	case MatchNumeric, MatchPercent:
+		diff, ok := a.distance(value)
+		return ok && diff <= a.allowed()
+	}
+	return false
+}
+
+// distance is how far a typed number is from the accepted one.
+func (a *AcceptedAnswer) distance(value string) (float64, bool) {
+	got, err := parseNumber(value)
+	if err != nil {
+		return 0, false
+	}
+	want, err := strconv.ParseFloat(a.Value, 64)
+	if err != nil {
+		return 0, false
+	}
+	return math.Abs(got - want), true
+}
+
+// allowed is the largest distance still accepted, turning a percentage into an absolute value.
+func (a *AcceptedAnswer) allowed() float64 {
+	if a.Match == MatchPercent {
+		want, _ := strconv.ParseFloat(a.Value, 64)
+		return math.Abs(want) * a.Tolerance / 100
+	}
+	return a.Tolerance
+}
+
+// describe names the match rule for display.
+func (a *AcceptedAnswer) describe() string {
+	switch a.Match {
+	case MatchCI:
+		return "case-insensitive"
+	case MatchNumeric:
+		return fmt.Sprintf("±%g", a.Tolerance)
+	case MatchPercent:
+		return fmt.Sprintf("±%g%%", a.Tolerance)
+	}
+	return a.Match
+}
+
+// parseAcceptedAnswer reads a markdown answer line. An optional [exact], [ci],
+// [regex], [~tolerance] or [~percent%] prefix overrides the question's default rule.
+func parseAcceptedAnswer(line string, match string, tolerance float64) (AcceptedAnswer, error) {
+	a := AcceptedAnswer{Value: strings.TrimSpace(line), Match: match, Tolerance: tolerance}
+	if strings.HasPrefix(a.Value, "[") {
+		if end := strings.Index(a.Value, "]"); end > 0 {
+			rule := a.Value[1:end]
+			rest := strings.TrimSpace(a.Value[end+1:])
+			switch {
+			case rule == MatchExact, rule == MatchCI, rule == MatchRegex:
+				a.Match, a.Value = rule, rest
+			case strings.HasPrefix(rule, "~"):
+				a.Match = MatchNumeric
+				tolStr := rule[1:]
+				if pct, ok := strings.CutSuffix(tolStr, "%"); ok {
+					a.Match, tolStr = MatchPercent, pct
+				}
+				tol, err := strconv.ParseFloat(tolStr, 64)
+				if err != nil {
+					return a, fmt.Errorf("invalid tolerance %q", rule)
+				}
+				a.Tolerance, a.Value = tol, rest
+			}
+		}
+	}
+	return a, a.validate()
+}
+
+// markdown writes the answer back as a line parseAcceptedAnswer reads, with a prefix
+// unless it uses the default rule of the question.
+func (a *AcceptedAnswer) markdown(match string) string {
+	switch {
+	case a.Match == MatchPercent:
+		return fmt.Sprintf("[~%g%%] %s", a.Tolerance, a.Value)
+	case a.Match == MatchNumeric && (match != MatchNumeric || a.Tolerance != 0):
+		return fmt.Sprintf("[~%g] %s", a.Tolerance, a.Value)
+	case a.Match != match:
+		return fmt.Sprintf("[%s] %s", a.Match, a.Value)
+	}
+	return a.Value
+}
+
+// isAccepted reports whether value matches any accepted answer.
+func (q *Question) isAccepted(value string) bool {
+	for i := range q.Accepted {
+		if q.Accepted[i].matches(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCorrectResponse grades the final answer of r, or the first one.
+func (q *Question) isCorrectResponse(r *Response, first bool) bool {
+	if q.IsSurvey {
+		return false
+	}
+
+	switch q.Kind {
+	case KindText, KindNumber:
+		if first {
+			return q.isAccepted(r.FirstValue)
+		}
+		return q.isAccepted(r.Value)
+	case KindRank:
+		if first {
+			return q.isCorrectOrder(r.FirstChoices)
+		}
+		return q.isCorrectOrder(r.Choices)
+	default:
+		if first {
+			return q.isCorrect(r.FirstChoices)
+		}
+		return q.isCorrect(r.Choices)
+	}
+}
+
+// isTyped reports whether the question is answered through a modal rather than buttons.
+func (q *Question) isTyped() bool {
+	return q.Kind == KindText || q.Kind == KindNumber
+}
+
+// openAnswerModal shows the free-text answer form of a question.
+func (b *Bot) openAnswerModal(e *gateway.InteractionCreateEvent, qId int64) error {
+	q, err := b.store.Question(qId)
+	if err != nil || q.IsClosed {
+		b.respondError(e, "Poll not found or closed")
+		return err
+	}
+
+	label := "Your answer"
+	if q.Kind == KindNumber {
+		label = "Your answer (a number)"
+	}
+
+	return b.s.RespondInteraction(e.ID, e.Token, api.InteractionResponse{
+		Type: api.ModalResponse,
+		Data: &api.InteractionResponseData{
+			CustomID: option.NewNullableString(fmt.Sprintf("txtm_%d", q.QID)),
+			Title:    option.NewNullableString(fmt.Sprintf("Answer #%d", q.QID)),
+			Components: discord.ComponentsPtr(
+				&discord.TextInputComponent{
+					CustomID:     "answer",
+					Style:        discord.TextInputShortStyle,
+					Label:        label,
+					LengthLimits: [2]int{1, 200},
+					Required:     true,
+				},
+			),
+		},
+	})
+}
+
+func (b *Bot) handleModalSubmit(e *gateway.InteractionCreateEvent) error {
+	data := e.Data.(*discord.ModalInteraction)
+
+	if strings.HasPrefix(string(data.CustomID), "editm_") {
+		qIdStr, _ := strings.CutPrefix(string(data.CustomID), "editm_")
+		qId, err := strconv.ParseInt(qIdStr, 10, 64)
+		if err != nil {
+			return err
+		}
+		return b.handleEditSubmit(e, qId)
+	}
+
+	if strings.HasPrefix(string(data.CustomID), "txtm_") {
+		qIdStr, _ := strings.CutPrefix(string(data.CustomID), "txtm_")
+		qId, err := strconv.ParseInt(qIdStr, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		input, ok := data.Components.Find("answer").(*discord.TextInputComponent)
+		if !ok || strings.TrimSpace(input.Value) == "" {
+			b.respondError(e, "Please provide an answer")
+			return nil
+		}
+
+		q, err := b.store.Question(qId)
+		if err != nil || q.IsClosed {
+			b.respondError(e, "Poll not found or closed")
+			return err
+		}
+		if q.Kind == KindNumber {
+			if _, err := parseNumber(input.Value); err != nil {
+				b.respondError(e, "Please answer with a number")
+				return nil
+			}
+		}
+
+		_, err = b.store.RecordValue(qId, int64(e.Member.User.ID), strings.TrimSpace(input.Value))
+		if errors.Is(err, ErrQuestionClosed) {
+			b.respondError(e, "Poll not found or closed")
+			return err
+		}
+		if err != nil {
+			b.respondError(e, "Failed to record response")
+			return err
+		}
+		if q.IsLive {
+			b.live.touch(qId)
+		}
+		b.respond(e, "🆗", discord.EphemeralMessage)
+	}
+
+	return nil
+}
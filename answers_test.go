@@ -0,0 +1,88 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAcceptedAnswerMatches(t *testing.T) {
+	for _, c := range []struct {
+		answer AcceptedAnswer
+		value  string
+		want   bool
+	}{
+		{AcceptedAnswer{Value: "Paris", Match: MatchExact}, " Paris ", true},
+		{AcceptedAnswer{Value: "Paris", Match: MatchExact}, "paris", false},
+		{AcceptedAnswer{Value: "Paris", Match: MatchCI}, "PARIS", true},
+		{AcceptedAnswer{Value: "Paris", Match: MatchCI}, "Pariss", false},
+		{AcceptedAnswer{Value: `colou?r`, Match: MatchRegex}, "the color red", true},
+		{AcceptedAnswer{Value: `^colou?r$`, Match: MatchRegex}, "the color red", false},
+		{AcceptedAnswer{Value: `(?i)^gr[ae]y$`, Match: MatchRegex}, "GREY", true},
+		{AcceptedAnswer{Value: `(`, Match: MatchRegex}, "(", false},
+		{AcceptedAnswer{Value: "Paris", Match: "fuzzy"}, "Paris", false},
+	} {
+		if got := c.answer.matches(c.value); got != c.want {
+			t.Errorf("%+v matches %q = %v, want %v", c.answer, c.value, got, c.want)
+		}
+	}
+}
+
+func TestRegexTimeout(t *testing.T) {
+	a := AcceptedAnswer{Value: `^(a+)+$`, Match: MatchRegex}
+	if err := a.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if a.matches(strings.Repeat("a", 40) + "!") {
+		t.Error("matched")
+	}
+	if elapsed := time.Since(start); elapsed > 10*regexTimeout {
+		t.Errorf("matching took %v", elapsed)
+	}
+}
+
+func TestValidateRegex(t *testing.T) {
+	for _, c := range []struct {
+		value string
+		ok    bool
+	}{
+		{`^\d{4}$`, true},
+		{strings.Repeat("a", maxRegexLength), true},
+		{strings.Repeat("a", maxRegexLength+1), false},
+		{`[a-`, false},
+	} {
+		a := AcceptedAnswer{Value: c.value, Match: MatchRegex}
+		if err := a.validate(); (err == nil) != c.ok {
+			t.Errorf("validate(%q) = %v", c.value, err)
+		}
+	}
+}
+
+func TestParseAcceptedAnswer(t *testing.T) {
+	for _, c := range []struct {
+		line string
+		want AcceptedAnswer
+		ok   bool
+	}{
+		{"Paris", AcceptedAnswer{Value: "Paris", Match: MatchCI}, true},
+		{"[exact] Paris", AcceptedAnswer{Value: "Paris", Match: MatchExact}, true},
+		{"[regex] ^Par", AcceptedAnswer{Value: "^Par", Match: MatchRegex}, true},
+		{"[~0.5] 3.14", AcceptedAnswer{Value: "3.14", Match: MatchNumeric, Tolerance: 0.5}, true},
+		{"[~5%] 200", AcceptedAnswer{Value: "200", Match: MatchPercent, Tolerance: 5}, true},
+		{"[other] Paris", AcceptedAnswer{Value: "[other] Paris", Match: MatchCI}, true},
+		{"[~x] 3", AcceptedAnswer{}, false},
+		{"[regex] (", AcceptedAnswer{}, false},
+	} {
+		got, err := parseAcceptedAnswer(c.line, MatchCI, 0)
+		if (err == nil) != c.ok || (c.ok && got != c.want) {
+			t.Errorf("parseAcceptedAnswer(%q) = %+v, %v", c.line, got, err)
+		}
+		if c.ok {
+			if back, _ := parseAcceptedAnswer(got.markdown(MatchCI), MatchCI, 0); back != got {
+				t.Errorf("markdown of %+v reads back as %+v", got, back)
+			}
+		}
+	}
+}
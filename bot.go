@@ -2,23 +2,23 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"log"
-	"os"
+	"time"
 
 	"github.com/diamondburned/arikawa/v3/api"
 	"github.com/diamondburned/arikawa/v3/discord"
 	"github.com/diamondburned/arikawa/v3/gateway"
 	"github.com/diamondburned/arikawa/v3/state"
 	"github.com/joho/godotenv"
-	_ "modernc.org/sqlite"
 )
 
 type Bot struct {
     s     *state.State
-    db    *sql.DB
-    token string
+    store QuestionStore
+    cfg   *Config
+    live  *liveUpdater
+    pages *pager
 }
 
 func main() {
@@ -26,35 +26,44 @@ func main() {
     if err != nil {
         panic(err)
     }
-    token := os.Getenv("BOT_TOKEN")
-    if token == "" {
-        log.Fatal("BOT_TOKEN environment variable is required")
+    cfg, err := loadConfig()
+    if err != nil {
+        log.Fatal(err)
     }
 
-    bot := &Bot{token: token}
+    bot := &Bot{cfg: cfg}
     if err := bot.Start(); err != nil {
         log.Fatal("Failed to start bot:", err)
     }
 }
 
 func (b *Bot) Start() error {
-    // Initialize SQLite
-    db, err := sql.Open("sqlite", "poll.db")
+    // Initialize the database, PostgreSQL when DATABASE_URL is set and SQLite otherwise
+    var store QuestionStore
+    var err error
+    if b.cfg.DatabaseURL != "" {
+        store, err = openSQLStore(postgresDialect, b.cfg.DatabaseURL)
+    } else {
+        store, err = openSQLStore(sqliteDialect, b.cfg.sqliteDSN())
+    }
     if err != nil {
-        return fmt.Errorf("failed to open database: %w", err)
+        return err
     }
-    b.db = db
-    defer b.db.Close()
+    b.store = store
+    b.live = newLiveUpdater(liveUpdateDelay, b.updateLivePosts)
+    b.pages = newPager()
+    defer b.store.Close()
 
-    // Create tables
-    if err := b.createTables(); err != nil {
-        return fmt.Errorf("failed to create tables: %w", err)
-    }
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    go b.sweepDrafts(ctx, time.Minute*10)
 
     // Initialize Discord client
-    b.s = state.New("Bot " + b.token)
+    b.s = state.New("Bot " + b.cfg.Token)
     b.s.AddHandler(b.handleInteraction)
     b.s.AddIntents(gateway.IntentGuilds | gateway.IntentGuildMessages)
+    go b.closeScheduled(ctx, time.Minute)
+    go b.postScheduled(ctx, time.Minute)
 
 	b.s.AddHandler(func(m *gateway.ReadyEvent) {
         if err := b.registerCommands(); err != nil {
@@ -63,44 +72,13 @@ func (b *Bot) Start() error {
 	})
 
     // Start the bot
-    if err := b.s.Connect(context.Background()); err != nil {
+    if err := b.s.Connect(ctx); err != nil {
         return fmt.Errorf("failed to connect: %w", err)
     }
 
     return nil
 }
 
-func (b *Bot) createTables() error {
-    queries := []string{
-        `CREATE TABLE IF NOT EXISTS questions (
-            id INTEGER PRIMARY KEY AUTOINCREMENT,
-            creator_id TEXT NOT NULL,
-            guild_id TEXT NOT NULL,
-            question TEXT NOT NULL,
-            options TEXT NOT NULL,
-            answer_id INTEGER NOT NULL,
-            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-            is_closed BOOLEAN DEFAULT FALSE,
-            is_anon BOOLEAN DEFAULT FALSE,
-        )`,
-        `CREATE TABLE IF NOT EXISTS responses (
-            question_id INTEGER,
-            user_id TEXT NOT NULL,
-            choice INTEGER NOT NULL,
-            responded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-            FOREIGN KEY(question_id) REFERENCES questions(id),
-            PRIMARY KEY (question_id, user_id)
-        )`,
-    }
-
-    for _, query := range queries {
-        if _, err := b.db.Exec(query); err != nil {
-            return err
-        }
-    }
-    return nil
-}
-
 func (b *Bot) registerCommands() error {
     perm := discord.PermissionManageChannels
     commands := []api.CreateCommandData{
@@ -113,17 +91,9 @@ func (b *Bot) registerCommands() error {
                     Description: "The question to ask",
                     Required:    true,
                 },
-                &discord.IntegerOption{
-                    OptionName:  "answer_id",
-                    Description: "Which option is correct?",
-                    Choices: []discord.IntegerChoice{
-                        {Name: "opt1", Value: 0},
-                        {Name: "opt2", Value: 1},
-                        {Name: "opt3", Value: 2},
-                        {Name: "opt4", Value: 3},
-                        {Name: "opt5", Value: 4},
-                        {Name: "opt6", Value: 5},
-                    },
+                &discord.StringOption{
+                    OptionName:  "answers",
+                    Description: "Which options are correct? Comma-separated option numbers (e.g. 1,3). Leave empty for a survey",
                     Required:    false,
                 },
                 &discord.StringOption{
@@ -161,6 +131,68 @@ func (b *Bot) registerCommands() error {
                     Description: "Hide replied users?",
                     Required:    false,
                 },
+                &discord.BooleanOption{
+                    OptionName:  "multi",
+                    Description: "Let users select more than one option?",
+                    Required:    false,
+                },
+                &discord.BooleanOption{
+                    OptionName:  "survey",
+                    Description: "No correct answer, just collect opinions?",
+                    Required:    false,
+                },
+                &discord.StringOption{
+                    OptionName:  "type",
+                    Description: "How to answer. Free-text options are the accepted answers, ranking options the correct order",
+                    Required:    false,
+                    Choices: []discord.StringChoice{
+                        {Name: "Multiple choice", Value: KindChoice},
+                        {Name: "Free text", Value: KindText},
+                        {Name: "Number", Value: KindNumber},
+                        {Name: "Ranking", Value: KindRank},
+                    },
+                },
+                &discord.StringOption{
+                    OptionName:  "match",
+                    Description: "How typed answers are compared to the accepted ones (default: case-insensitive)",
+                    Required:    false,
+                    Choices: []discord.StringChoice{
+                        {Name: "Exact", Value: MatchExact},
+                        {Name: "Case-insensitive", Value: MatchCI},
+                        {Name: "Regex", Value: MatchRegex},
+                        {Name: "Number within tolerance", Value: MatchNumeric},
+                    },
+                },
+                &discord.NumberOption{
+                    OptionName:  "tolerance",
+                    Description: "Allowed difference for numeric matching",
+                    Required:    false,
+                },
+                &discord.NumberOption{
+                    OptionName:  "target",
+                    Description: "Correct value of a number question. Leave empty for a survey",
+                    Required:    false,
+                },
+                &discord.BooleanOption{
+                    OptionName:  "percent",
+                    Description: "Is the tolerance a percentage of the target?",
+                    Required:    false,
+                },
+                &discord.StringOption{
+                    OptionName:  "close",
+                    Description: "Close automatically after a duration (30m, 2h, 1d) or at a time (09:00, 2024-05-01 18:00, <t:unix>)",
+                    Required:    false,
+                },
+                &discord.BooleanOption{
+                    OptionName:  "live",
+                    Description: "Show running counts on the post?",
+                    Required:    false,
+                },
+                &discord.StringOption{
+                    OptionName:  "tags",
+                    Description: "Comma-separated tags to find the poll by (e.g. networking,linux)",
+                    Required:    false,
+                },
             },
             DefaultMemberPermissions: &perm,
         },
@@ -190,6 +222,52 @@ func (b *Bot) registerCommands() error {
                     Description: "Comma-separated list of question IDs (e.g. 1,2,3)",
                     Required:    true,
                 },
+                &discord.StringOption{
+                    OptionName:  "close",
+                    Description: "Close automatically after a duration (30m, 2h, 1d) or at a time (09:00, 2024-05-01 18:00, <t:unix>)",
+                    Required:    false,
+                },
+            },
+            DefaultMemberPermissions: &perm,
+        },
+        {
+            Name:        "clone",
+            Description: "Copy polls into a new poll, here or in another server",
+            Options: []discord.CommandOption{
+                &discord.StringOption{
+                    OptionName:  "question_ids",
+                    Description: "Comma-separated list of question IDs (e.g. 1,2,3)",
+                    Required:    true,
+                },
+                &discord.StringOption{
+                    OptionName:  "guild",
+                    Description: "ID of the server to copy into (defaults to this one)",
+                    Required:    false,
+                },
+            },
+            DefaultMemberPermissions: &perm,
+        },
+        {
+            Name:        "edit",
+            Description: "Edit a poll and its posts",
+            Options: []discord.CommandOption{
+                &discord.IntegerOption{
+                    OptionName:  "question_id",
+                    Description: "ID of the poll",
+                    Required:    true,
+                },
+            },
+            DefaultMemberPermissions: &perm,
+        },
+        {
+            Name:        "where",
+            Description: "Show where a poll has been posted",
+            Options: []discord.CommandOption{
+                &discord.IntegerOption{
+                    OptionName:  "question_id",
+                    Description: "ID of the poll",
+                    Required:    true,
+                },
             },
             DefaultMemberPermissions: &perm,
         },
@@ -212,13 +290,56 @@ func (b *Bot) registerCommands() error {
                 &discord.StringOption{
                     OptionName:  "question_ids",
                     Description: "Comma-separated list of question IDs (e.g. 1,2,3)",
-                    Required:    true,
+                    Required:    false,
+                },
+                &discord.StringOption{
+                    OptionName:  "tags",
+                    Description: "Analyze every question with any of these comma-separated tags",
+                    Required:    false,
+                },
+                &discord.StringOption{
+                    OptionName:  "since",
+                    Description: "Only questions created after this (7d, 2024-05-01, <t:unix>)",
+                    Required:    false,
+                },
+                &discord.StringOption{
+                    OptionName:  "until",
+                    Description: "Only questions created before this (1d, 2024-06-01, <t:unix>)",
+                    Required:    false,
+                },
+                &discord.StringOption{
+                    OptionName:  "status",
+                    Description: "Only open or closed questions",
+                    Choices: []discord.StringChoice{
+                        {Name: "open", Value: "open"},
+                        {Name: "closed", Value: "closed"},
+                    },
+                    Required:    false,
+                },
+                &discord.UserOption{
+                    OptionName:  "creator",
+                    Description: "Only questions created by this user",
+                    Required:    false,
+                },
+                &discord.BooleanOption{
+                    OptionName:  "anon",
+                    Description: "Only anonymous questions, or only the others",
+                    Required:    false,
                 },
                 &discord.BooleanOption{
                     OptionName:  "public",
                     Description: "Show to everyone",
                     Required:    false,
                 },
+                &discord.StringOption{
+                    OptionName:  "attempt",
+                    Description: "Score the first click or the final answer",
+                    Choices: []discord.StringChoice{
+                        {Name: "final", Value: "final"},
+                        {Name: "first", Value: "first"},
+                    },
+                    Required:    false,
+                },
             },
             DefaultMemberPermissions: &perm,
         },
@@ -228,7 +349,50 @@ func (b *Bot) registerCommands() error {
             Options: []discord.CommandOption {
                 &discord.IntegerOption{
                     OptionName:  "count",
-                    Description: "How many recent questions to show? <1-50>",
+                    Description: "How many questions to show? <1-200>",
+                    Required:    false,
+                },
+                &discord.StringOption{
+                    OptionName:  "tags",
+                    Description: "Only show questions with any of these comma-separated tags",
+                    Required:    false,
+                },
+                &discord.StringOption{
+                    OptionName:  "since",
+                    Description: "Only questions created after this (7d, 2024-05-01, <t:unix>)",
+                    Required:    false,
+                },
+                &discord.StringOption{
+                    OptionName:  "until",
+                    Description: "Only questions created before this (1d, 2024-06-01, <t:unix>)",
+                    Required:    false,
+                },
+                &discord.StringOption{
+                    OptionName:  "status",
+                    Description: "Only open or closed questions",
+                    Choices: []discord.StringChoice{
+                        {Name: "open", Value: "open"},
+                        {Name: "closed", Value: "closed"},
+                    },
+                    Required:    false,
+                },
+                &discord.UserOption{
+                    OptionName:  "creator",
+                    Description: "Only questions created by this user",
+                    Required:    false,
+                },
+                &discord.BooleanOption{
+                    OptionName:  "anon",
+                    Description: "Only anonymous questions, or only the others",
+                    Required:    false,
+                },
+                &discord.StringOption{
+                    OptionName:  "order",
+                    Description: "Show the newest or the oldest questions first",
+                    Choices: []discord.StringChoice{
+                        {Name: "newest", Value: "newest"},
+                        {Name: "oldest", Value: "oldest"},
+                    },
                     Required:    false,
                 },
                 &discord.BooleanOption{
@@ -239,6 +403,165 @@ func (b *Bot) registerCommands() error {
             },
             DefaultMemberPermissions: &perm,
         },
+        {
+            Name:        "quiz",
+            Description: "Manage named sets of questions",
+            Options: []discord.CommandOption{
+                &discord.SubcommandOption{
+                    OptionName:  "create",
+                    Description: "Create a quiz, optionally with questions",
+                    Options: []discord.CommandOptionValue{
+                        &discord.StringOption{
+                            OptionName:  "name",
+                            Description: "Name of the quiz",
+                            Required:    true,
+                        },
+                        &discord.StringOption{
+                            OptionName:  "question_ids",
+                            Description: "Comma-separated list of question IDs (e.g. 1,2,3)",
+                            Required:    false,
+                        },
+                    },
+                },
+                &discord.SubcommandOption{
+                    OptionName:  "add",
+                    Description: "Add questions to the end of a quiz",
+                    Options: []discord.CommandOptionValue{
+                        &discord.StringOption{
+                            OptionName:  "name",
+                            Description: "Name of the quiz",
+                            Required:    true,
+                        },
+                        &discord.StringOption{
+                            OptionName:  "question_ids",
+                            Description: "Comma-separated list of question IDs (e.g. 1,2,3)",
+                            Required:    true,
+                        },
+                    },
+                },
+                &discord.SubcommandOption{
+                    OptionName:  "post",
+                    Description: "Post every question of a quiz here",
+                    Options: []discord.CommandOptionValue{
+                        &discord.StringOption{
+                            OptionName:  "name",
+                            Description: "Name of the quiz",
+                            Required:    true,
+                        },
+                        &discord.StringOption{
+                            OptionName:  "close",
+                            Description: "Close automatically after a duration (30m, 2h, 1d) or at a time (09:00, 2024-05-01 18:00, <t:unix>)",
+                            Required:    false,
+                        },
+                    },
+                },
+                &discord.SubcommandOption{
+                    OptionName:  "close",
+                    Description: "Close every question of a quiz",
+                    Options: []discord.CommandOptionValue{
+                        &discord.StringOption{
+                            OptionName:  "name",
+                            Description: "Name of the quiz",
+                            Required:    true,
+                        },
+                    },
+                },
+                &discord.SubcommandOption{
+                    OptionName:  "analyze",
+                    Description: "Analyze the questions of a quiz together",
+                    Options: []discord.CommandOptionValue{
+                        &discord.StringOption{
+                            OptionName:  "name",
+                            Description: "Name of the quiz",
+                            Required:    true,
+                        },
+                        &discord.BooleanOption{
+                            OptionName:  "public",
+                            Description: "Show to everyone",
+                            Required:    false,
+                        },
+                        &discord.StringOption{
+                            OptionName:  "attempt",
+                            Description: "Score the first click or the final answer",
+                            Choices: []discord.StringChoice{
+                                {Name: "final", Value: "final"},
+                                {Name: "first", Value: "first"},
+                            },
+                            Required:    false,
+                        },
+                    },
+                },
+                &discord.SubcommandOption{
+                    OptionName:  "list",
+                    Description: "Show the quizzes of this server",
+                },
+            },
+            DefaultMemberPermissions: &perm,
+        },
+        {
+            Name:        "schedule",
+            Description: "Queue questions to be posted later",
+            Options: []discord.CommandOption{
+                &discord.SubcommandOption{
+                    OptionName:  "add",
+                    Description: "Post questions at a later time, optionally spread out",
+                    Options: []discord.CommandOptionValue{
+                        &discord.StringOption{
+                            OptionName:  "question_ids",
+                            Description: "Comma-separated list of question IDs (e.g. 1,2,3)",
+                            Required:    true,
+                        },
+                        &discord.StringOption{
+                            OptionName:  "at",
+                            Description: "When to post the first question (30m, 1d, 09:00, 2024-05-01 18:00, <t:unix>)",
+                            Required:    true,
+                        },
+                        &discord.StringOption{
+                            OptionName:  "every",
+                            Description: "Post the next question this much later (e.g. 1d for one per day)",
+                            Required:    false,
+                        },
+                        &discord.ChannelOption{
+                            OptionName:  "channel",
+                            Description: "Where to post (default: here)",
+                            Required:    false,
+                        },
+                        &discord.StringOption{
+                            OptionName:  "close",
+                            Description: "Close each question this long after it is posted (30m, 2h, 1d)",
+                            Required:    false,
+                        },
+                    },
+                },
+                &discord.SubcommandOption{
+                    OptionName:  "list",
+                    Description: "Show the queued posts",
+                },
+                &discord.SubcommandOption{
+                    OptionName:  "cancel",
+                    Description: "Remove queued posts",
+                    Options: []discord.CommandOptionValue{
+                        &discord.StringOption{
+                            OptionName:  "schedule_ids",
+                            Description: "Comma-separated list of schedule IDs from /schedule list",
+                            Required:    true,
+                        },
+                    },
+                },
+                &discord.SubcommandOption{
+                    OptionName:  "timezone",
+                    Description: "Set the timezone times are read in",
+                    Options: []discord.CommandOptionValue{
+                        &discord.StringOption{
+                            OptionName:  "timezone",
+                            Description: "IANA timezone name (e.g. Europe/Berlin, Asia/Taipei, UTC)",
+                            Required:    true,
+                        },
+                    },
+                },
+            },
+            DefaultMemberPermissions: &perm,
+        },
         {
             Type: discord.MessageCommand,
             Name:        "Make questions",
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -34,9 +35,101 @@ func (b *Bot) handleAnalyzeCommand(e *gateway.InteractionCreateEvent) error {
         }
     }
 
-    // Parse question IDs
-    questionIDs := strings.Split(data.Options[0].String(), ",")
+    // Score the first click or the final answer
+    firstAttempt := false
+    if opt := data.Options.Find("attempt"); opt.Name != "" {
+        firstAttempt = opt.String() == "first"
+    }
+
+    filter, err := b.questionFilter(e, data.Options)
+    if err != nil {
+        b.respondError(e, err.Error())
+        return nil
+    }
+
+    questionIDs := parseIds(data.Options.Find("question_ids").String())
+    if len(questionIDs) == 0 && filter.isEmpty() {
+        b.respondError(e, "Please provide question IDs or filters that match questions")
+        return nil
+    }
+    if len(questionIDs) > maxAnalyzedQuestions {
+        b.respondError(e, fmt.Sprintf("Please list at most %d questions", maxAnalyzedQuestions))
+        return nil
+    }
+
+    // Loading the responses of many questions can take longer than Discord waits
+    if err := b.deferResponse(e, analysisFlags(showToEveryone)); err != nil {
+        return err
+    }
+
+    // Analyze the listed questions that match the filters, or the latest questions matching them
+    var questions []*Question
+    var note string
+    if len(questionIDs) > 0 {
+        questions, err = b.guildQuestions(e.GuildID, questionIDs)
+        if err != nil {
+            b.editResponseError(e, err.Error())
+            return nil
+        }
+        questions = slices.DeleteFunc(questions, func(q *Question) bool { return !filter.matches(q) })
+        if len(questions) == 0 {
+            b.editResponseError(e, "None of the listed questions match the filters")
+            return nil
+        }
+    } else {
+        filter.NewestFirst = true
+        questions, err = b.store.RecentQuestions(int64(e.GuildID), filter, maxAnalyzedQuestions+1)
+        if err != nil {
+            b.editResponseError(e, "Failed to get questions")
+            return err
+        }
+        if len(questions) == 0 {
+            b.editResponseError(e, "No questions match the filters")
+            return nil
+        }
+        if len(questions) > maxAnalyzedQuestions {
+            questions = questions[:maxAnalyzedQuestions]
+            note = fmt.Sprintf("-# Only the latest %d matching questions are analyzed\n", maxAnalyzedQuestions)
+        }
+        slices.Reverse(questions)
+    }
+
+    b.analyzeQuestions(e, questions, note, firstAttempt)
+    return nil
+}
+
+// maxAnalyzedQuestions bounds how many questions one analysis loads.
+const maxAnalyzedQuestions = 200
+
+func analysisFlags(showToEveryone bool) discord.MessageFlags {
+    if showToEveryone {
+        return 0
+    }
+    return discord.EphemeralMessage
+}
+
+// guildQuestions loads the listed questions, skipping the ones that do not exist.
+// It fails with a message for the user when one belongs to another guild.
+func (b *Bot) guildQuestions(guildID discord.GuildID, questionIDs []int64) ([]*Question, error) {
+    var questions []*Question
+    for _, qID := range questionIDs {
+        q, err := b.store.Question(qID)
+        if err != nil {
+            continue
+        }
+        if q.GuildID != int64(guildID) {
+            return nil, fmt.Errorf("Q#%d is not your poll!", qID)
+        }
+        questions = append(questions, q)
+    }
+    return questions, nil
+}
+
+// analyzeQuestions scores the questions together and fills in the deferred response
+// with the statistics, after the note.
+func (b *Bot) analyzeQuestions(e *gateway.InteractionCreateEvent, questions []*Question, note string, firstAttempt bool) {
     var result strings.Builder
+    result.WriteString(note)
     
     // Track statistics
     type UserStats struct {
@@ -55,135 +148,152 @@ func (b *Bot) handleAnalyzeCommand(e *gateway.InteractionCreateEvent) error {
     totalCorrect := 0
     totalAnswers := 0
     anyAnon := false
+    surveyCount := 0
 
     // Analyze each question
-    for _, qIDStr := range questionIDs {
-        qID, err := strconv.ParseInt(strings.TrimSpace(qIDStr), 10, 64)
-        if err != nil {
-            continue
-        }
+    for _, q := range questions {
+        qID := q.QID
 
-        // Get question info
-        var question string
-        var options string
-        var correctAnswerID int
-        var guildId string
-        var isClosed bool
-        var isAnon bool
-        err = b.db.QueryRow(
-            "SELECT question, options, answer_id, guild_id, is_closed, is_anon FROM questions WHERE id = ?",
-            qID,
-        ).Scan(&question, &options, &correctAnswerID, &guildId, &isClosed, &isAnon)
-        if err != nil {
-            continue
-        }
-
-        if guildId != e.GuildID.String() {
-            b.respondError(e, fmt.Sprintf("Q#%d is not your poll!", qID))
-            return err
-        }
-
-        if isClosed {
+        if q.IsClosed {
             result.WriteString("\n🔒")
         } else {
             result.WriteString("\n🔓")
         }
-        if isAnon {
+        if q.IsAnon {
             result.WriteString("㊙️")
             anyAnon = true
         }
 
-        if strings.Contains(question, "\n") {
-            result.WriteString(fmt.Sprintf("**#%d**\n%s\n", qID, question))
+        if strings.Contains(q.Question, "\n") {
+            result.WriteString(fmt.Sprintf("**#%d**\n%s\n", qID, q.Question))
         } else {
-            result.WriteString(fmt.Sprintf("**#%d**: %s\n", qID, question))
+            result.WriteString(fmt.Sprintf("**#%d**: %s\n", qID, q.Question))
         }
-        optionsList := strings.Split(options, "|")
         
         // Get responses
-        rows, err := b.db.Query(`
-            SELECT 
-                choice,
-                COUNT(*) as count,
-                GROUP_CONCAT(user_id) as users
-            FROM responses 
-            WHERE question_id = ?
-            GROUP BY choice
-            ORDER BY choice`,
-            qID,
-        )
+        responses, err := b.store.Responses(qID)
         if err != nil {
             continue
         }
 
+        // Surveys have no correct answer, so only show how the answers are spread
+        if q.IsSurvey {
+            surveyCount++
+            optionCounts := make(map[int]int)
+            for _, r := range responses {
+                choices := r.Choices
+                if firstAttempt {
+                    choices = r.FirstChoices
+                }
+                for _, choice := range choices {
+                    optionCounts[choice]++
+                }
+            }
+            if len(responses) == 0 {
+                result.WriteString("❌ *No responses*\n")
+            } else if q.Kind == KindText {
+                writeValueDistribution(&result, responses, firstAttempt)
+            } else if q.Kind == KindNumber {
+                writeNumberDistribution(&result, responses, firstAttempt)
+            } else if q.Kind == KindRank {
+                writeBordaRanking(&result, q, responses, firstAttempt)
+            } else {
+                for i, opt := range q.Options {
+                    percentage := float64(optionCounts[i]) * 100 / float64(len(responses))
+                    result.WriteString(fmt.Sprintf("📊 **%s**: %d (%.1f%%)\n", opt.Label, optionCounts[i], percentage))
+                }
+            }
+            result.WriteString("\n")
+            continue
+        }
+
         qStats := QuestionStats{
             id:       qID,
-            question: question,
+            question: q.Question,
         }
 
         // Process responses
         totalResponses := 0
+        correctCount := 0
+        closeCount := 0
         optionCounts := make(map[int]int)
-        optionUsers := make(map[int][]string)
         
-        for rows.Next() {
-            var choice string
-            var count int
-            var users string
-            rows.Scan(&choice, &count, &users)
-            
-            choiceIdx, _ := strconv.Atoi(choice)
-            optionCounts[choiceIdx] = count
-            optionUsers[choiceIdx] = strings.Split(users, ",")
-            totalResponses += count
-        }
-        rows.Close()
-
-        // Show results for each option
-        for i, opt := range optionsList {
-            count := optionCounts[i]
-            if totalResponses > 0 {
-                percentage := float64(count) * 100 / float64(totalResponses)
-                
-                if i == correctAnswerID {
-                    result.WriteString(fmt.Sprintf("✅ **%s**: %d (%.1f%%)\n", opt, count, percentage))
-                    qStats.correct = count
-                    qStats.correctUsers = optionUsers[i]
-                    
-                    if !isAnon {
-                        // Update user stats
-                        for _, userID := range optionUsers[i] {
-                            if _, exists := userStats[userID]; !exists {
-                                userStats[userID] = &UserStats{}
-                            }
-                            userStats[userID].correct++
-                        }
-                    }
+        for _, r := range responses {
+            choices := r.Choices
+            if firstAttempt {
+                choices = r.FirstChoices
+            }
+            for _, choice := range choices {
+                optionCounts[choice]++
+            }
+            totalResponses++
+
+            userID := strconv.FormatInt(r.UserID, 10)
+            correct := q.isCorrectResponse(&r, firstAttempt)
+            if q.Kind == KindNumber && !correct {
+                value := r.Value
+                if firstAttempt {
+                    value = r.FirstValue
+                }
+                if q.grade(value) == GradeClose {
+                    closeCount++
                 }
-                
-                // result.WriteString(fmt.Sprintf("**%s**: %d (%.1f%%)\n", opt, count, percentage))
-                
-                // Update total stats for this question
-                qStats.total += count
-                
-                if !isAnon {
-                    // Update user totals
-                    for _, userID := range optionUsers[i] {
-                        if _, exists := userStats[userID]; !exists {
-                            userStats[userID] = &UserStats{}
-                        }
-                        userStats[userID].total++
-                    }
+            }
+            if correct {
+                correctCount++
+                qStats.correctUsers = append(qStats.correctUsers, userID)
+            }
+
+            if !q.IsAnon {
+                // Update user stats
+                if _, exists := userStats[userID]; !exists {
+                    userStats[userID] = &UserStats{}
+                }
+                userStats[userID].total++
+                if correct {
+                    userStats[userID].correct++
                 }
             }
         }
+        qStats.correct = correctCount
+        qStats.total = totalResponses
+
+        // Show results for each correct option
+        if totalResponses > 0 {
+            for i, opt := range q.Options {
+                if opt.IsCorrect {
+                    count := optionCounts[i]
+                    percentage := float64(count) * 100 / float64(totalResponses)
+                    result.WriteString(fmt.Sprintf("✅ **%s**: %d (%.1f%%)\n", opt.Label, count, percentage))
+                }
+            }
+            if q.IsMulti {
+                percentage := float64(correctCount) * 100 / float64(totalResponses)
+                result.WriteString(fmt.Sprintf("☑️ **All correct options**: %d (%.1f%%)\n", correctCount, percentage))
+            }
+            if q.Kind == KindText {
+                percentage := float64(correctCount) * 100 / float64(totalResponses)
+                result.WriteString(fmt.Sprintf("✅ **Accepted answers**: %d (%.1f%%)\n", correctCount, percentage))
+            }
+            if q.Kind == KindNumber {
+                percentage := float64(correctCount) * 100 / float64(totalResponses)
+                closePercentage := float64(closeCount) * 100 / float64(totalResponses)
+                result.WriteString(fmt.Sprintf("✅ **Correct**: %d (%.1f%%), 🟡 **Close**: %d (%.1f%%)\n", correctCount, percentage, closeCount, closePercentage))
+                writeNumberDistribution(&result, responses, firstAttempt)
+                writeClosestUsers(&result, q, responses, firstAttempt)
+            }
+            if q.Kind == KindRank {
+                percentage := float64(correctCount) * 100 / float64(totalResponses)
+                result.WriteString(fmt.Sprintf("✅ **Correct order**: %d (%.1f%%)\n", correctCount, percentage))
+                writeRankDistances(&result, q, responses, firstAttempt)
+            }
+        }
         
         if totalResponses == 0 {
             result.WriteString("❌ *No responses*\n")
         }
 
-        if correctAnswerID >= 0 && totalResponses > 0 {
-            correctCount := optionCounts[correctAnswerID]
+        if totalResponses > 0 {
             // correctPercentage := float64(correctCount) * 100 / float64(totalResponses)
             // result.WriteString(fmt.Sprintf("\nCorrect answers: %d (%.1f%%)\n", correctCount, correctPercentage))
             
@@ -197,13 +307,17 @@ func (b *Bot) handleAnalyzeCommand(e *gateway.InteractionCreateEvent) error {
         result.WriteString("\n")
     }
 
-    if len(questionStats) == 0 {
-        b.respondError(e, "❌ *No question/response data!*")
-        return err
+    if len(questionStats) == 0 && surveyCount == 0 {
+        b.editResponseError(e, "❌ *No question/response data!*")
+        return
     }
 
     // Generate summary
-    result.WriteString("### \n**Summary**\n\n")
+    if firstAttempt {
+        result.WriteString("### \n**Summary** (first attempts)\n\n")
+    } else {
+        result.WriteString("### \n**Summary**\n\n")
+    }
     
     // Top 10 users
     type UserRank struct {
@@ -223,6 +337,9 @@ func (b *Bot) handleAnalyzeCommand(e *gateway.InteractionCreateEvent) error {
     if anyAnon {
         result.WriteString("-# ㊙️ *Anonymous answers are not counted*\n")
     }
+    if surveyCount > 0 {
+        result.WriteString("-# 📊 *Surveys are not scored*\n")
+    }
     for i := 0; i < len(userRanks) && i < 10; i++ {
         user := userRanks[i]
         if user.total > 0 {
@@ -264,11 +381,34 @@ func (b *Bot) handleAnalyzeCommand(e *gateway.InteractionCreateEvent) error {
         result.WriteString(fmt.Sprintf("Correct answers: %d (%.1f%%)\n", totalCorrect, overallPercentage))
     }
 
-    if showToEveryone {
-        b.respond(e, result.String(), 0)
-    } else {
-        b.respond(e, result.String(), discord.EphemeralMessage)
+    b.editPages(e, result.String())
+}
+
+// writeValueDistribution lists the most common typed answers.
+func writeValueDistribution(result *strings.Builder, responses []Response, firstAttempt bool) {
+    counts := make(map[string]int)
+    var values []string
+    for _, r := range responses {
+        value := r.Value
+        if firstAttempt {
+            value = r.FirstValue
+        }
+        value = strings.ToLower(strings.TrimSpace(value))
+        if counts[value] == 0 {
+            values = append(values, value)
+        }
+        counts[value]++
     }
+    sort.SliceStable(values, func(i, j int) bool {
+        return counts[values[i]] > counts[values[j]]
+    })
 
-    return nil
-}
\ No newline at end of file
+    for i, value := range values {
+        if i == 10 {
+            result.WriteString(fmt.Sprintf("*And %d more...*\n", len(values) - i))
+            break
+        }
+        percentage := float64(counts[value]) * 100 / float64(len(responses))
+        result.WriteString(fmt.Sprintf("📊 `%s`: %d (%.1f%%)\n", value, counts[value], percentage))
+    }
+}
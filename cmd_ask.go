@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"math/rand/v2"
+	"strconv"
 	"time"
 
 	"github.com/diamondburned/arikawa/v3/api"
@@ -24,22 +25,69 @@ func (b *Bot) handleAskCommand(e *gateway.InteractionCreateEvent) error {
 
 	data := e.Data.(*discord.CommandInteraction)
 	question := data.Options[0].String()
-	options := make([]string, 0)
+	options := make([]QuestionOption, 0)
 	isAnon := false
-	var answerId = 0
+	isMulti := false
+	isSurvey := false
+	isLive := false
+	var answers []int64
+	hasAnswers := false
+	kind := KindChoice
+	match := MatchCI
+	tolerance := 0.0
+	var target *float64
+	var closeAt *time.Time
+	var closeIn time.Duration
+	isPercent := false
+	var tags []string
 
 	// Collect options
 	for i := 1; i < len(data.Options); i++ {
 		switch data.Options[i].Name {
-			case "answer":
-			case "answer_id":
-				aId, _ := data.Options[i].IntValue()
-				answerId = int(aId)
+			case "answers":
+				answers = parseIds(data.Options[i].String())
+				hasAnswers = true
+			case "survey":
+				isSurvey, _ = data.Options[i].BoolValue()
 			case "anon":
 				isAnon, _ = data.Options[i].BoolValue()
+			case "multi":
+				isMulti, _ = data.Options[i].BoolValue()
+			case "live":
+				isLive, _ = data.Options[i].BoolValue()
+			case "type":
+				kind = data.Options[i].String()
+			case "match":
+				match = data.Options[i].String()
+			case "tolerance":
+				tolerance, _ = data.Options[i].FloatValue()
+			case "target":
+				v, _ := data.Options[i].FloatValue()
+				target = &v
+			case "percent":
+				isPercent, _ = data.Options[i].BoolValue()
+			case "tags":
+				tags = parseTags(data.Options[i].String())
+			case "close":
+				// A duration counts from when the question is posted, not from now
+				if d, err := parseInterval(data.Options[i].String()); err == nil {
+					closeIn = d
+					break
+				}
+				now := b.guildNow(e.GuildID)
+				at, err := parseWhen(data.Options[i].String(), now)
+				if err != nil {
+					b.respondError(e, fmt.Sprintf("Invalid close time: %v", err))
+					return nil
+				}
+				if !at.After(now) {
+					b.respondError(e, "The close time has already passed")
+					return nil
+				}
+				closeAt = &at
 			default:
 				if data.Options[i].String() != "" {
-					options = append(options, data.Options[i].String())
+					options = append(options, QuestionOption{Label: data.Options[i].String()})
 				}
 		}
 	}
@@ -49,44 +97,123 @@ func (b *Bot) handleAskCommand(e *gateway.InteractionCreateEvent) error {
 		return nil
 	}
 
-	if len(options) < 1 {
+	// The options of a free-text question are its accepted answers
+	var accepted []AcceptedAnswer
+	if kind == KindNumber {
+		if hasAnswers || isMulti || len(options) > 0 {
+			b.respondError(e, "Number questions take their answer from the target")
+			return nil
+		}
+		if target != nil {
+			a := AcceptedAnswer{Value: strconv.FormatFloat(*target, 'g', -1, 64), Match: MatchNumeric, Tolerance: tolerance}
+			if isPercent {
+				a.Match = MatchPercent
+			}
+			if err := a.validate(); err != nil {
+				b.respondError(e, fmt.Sprintf("Invalid target: %v", err))
+				return nil
+			}
+			accepted = append(accepted, a)
+		}
+		hasAnswers = len(accepted) > 0
+	} else if kind == KindText {
+		if hasAnswers || isMulti {
+			b.respondError(e, "Free-text questions take their accepted answers from the options")
+			return nil
+		}
+		for _, opt := range options {
+			a := AcceptedAnswer{Value: opt.Label, Match: match, Tolerance: tolerance}
+			if err := a.validate(); err != nil {
+				b.respondError(e, fmt.Sprintf("Invalid accepted answer: %v", err))
+				return nil
+			}
+			accepted = append(accepted, a)
+		}
+		options = nil
+		hasAnswers = len(accepted) > 0
+	} else if kind == KindRank {
+		if hasAnswers || isMulti {
+			b.respondError(e, "Ranking questions take their correct order from the options")
+			return nil
+		}
+		if len(options) < 2 {
+			b.respondError(e, "Please provide at least two options to rank")
+			return nil
+		}
+		hasAnswers = !isSurvey
+	} else if len(options) < 1 {
 		b.respondError(e, "Please provide options")
 		return nil
 	}
 
+	// Without answers the question is a survey rather than silently scored
+	if isSurvey && hasAnswers {
+		b.respondError(e, "Surveys have no correct answer")
+		return nil
+	}
+	if !hasAnswers {
+		isSurvey = true
+	}
+
+	// Answers are numbered from 1 like the option names
+	if kind == KindChoice && hasAnswers && len(answers) == 0 {
+		b.respondError(e, "Please provide answers as option numbers (e.g. 1,3)")
+		return nil
+	}
+	for _, a := range answers {
+		if a < 1 || int(a) > len(options) {
+			b.respondError(e, fmt.Sprintf("There is no option %d", a))
+			return nil
+		}
+		options[a-1].IsCorrect = true
+	}
+
 	q := &Question{
 		CreatorID: int64(e.Member.User.ID),
 		GuildID:   int64(e.GuildID),
 		Question:  question,
 		Options:   options,
-		Answer:    int64(answerId),
-		IsAnon: isAnon,
+		IsAnon:    isAnon,
+		IsMulti:   isMulti,
+		IsSurvey:  isSurvey,
+		Kind:      kind,
+		Accepted:  accepted,
+		CloseAt:   closeAt,
+		IsLive:    isLive,
+		Tags:      tags,
 	}
 
 	d := QuestionDraft{
-		Question:   q,
-		DraftID:    rand.Int64(),
-		lastEdited: time.Now(),
+		Question:  q,
+		ChannelID: int64(e.ChannelID),
+		ExpiresAt: time.Now().Add(draftTTL),
+		CloseIn:   closeIn,
 	}
 
-	for _, ok := drafts[d.DraftID]; ok; {
-		d.DraftID = rand.Int64()
+	if err := b.store.CreateDraft(&d); err != nil {
+		b.respondError(e, "Failed to save draft")
+		return err
 	}
 
-	drafts[d.DraftID] = &d
-
-	// Create buttons
-	components := make([]discord.Component, len(options), len(options)+2)
-	for i, opt := range options {
+	// Create buttons, shuffling a scored ranking so the preview does not give the order away
+	order := make([]int, len(options))
+	for i := range order {
+		order[i] = i
+	}
+	if kind == KindRank && !isSurvey {
+		order = rand.Perm(len(options))
+	}
+	components := make([]discord.InteractiveComponent, len(options))
+	for i, o := range order {
+		opt := options[o]
 		components[i] = &discord.ButtonComponent{
 			Style:    discord.PrimaryButtonStyle(),
 			CustomID: discord.ComponentID(fmt.Sprintf("p_opt_%d", i)),
-			Label:    opt,
+			Label:    truncate(opt.Label, maxButtonLabel),
 		}
 	}
 
-	components = append(
-		components,
+	controls := []discord.InteractiveComponent{
 		&discord.ButtonComponent{
 			CustomID: discord.ComponentID(fmt.Sprintf("ask_%d", d.DraftID)),
 			Label:    "✅",
@@ -97,18 +224,52 @@ func (b *Bot) handleAskCommand(e *gateway.InteractionCreateEvent) error {
 			Label:    "❌",
 			Style:    discord.DangerButtonStyle(),
 		},
-	)
+	}
 
+	if kind == KindText || kind == KindNumber {
+		if kind == KindText {
+			question = "[✏️ Type your answer]\n" + question
+		} else {
+			question = "[🔢 Answer with a number]\n" + question
+		}
+		components = append([]discord.InteractiveComponent{
+			&discord.ButtonComponent{
+				CustomID: "p_txt",
+				Label:    "Answer",
+				Emoji:    &discord.ComponentEmoji{Name: "✏️"},
+				Style:    discord.PrimaryButtonStyle(),
+			},
+		}, components...)
+	}
+	if kind == KindRank {
+		question = "[🏆 Click the options in order]\n" + question
+	}
+	if isMulti {
+		question = "[☑️ Select all that apply]\n" + question
+	}
+	if isSurvey {
+		question = "[📊 Survey]\n" + question
+	}
+	if isLive {
+		question = "[📶 Live counts]\n" + question
+	}
 	if isAnon {
 		question = "[㊙️ Anonymous]\n" + question
 	}
+	if closeAt != nil {
+		question += fmt.Sprintf("\n-# Closes <t:%d:R>", closeAt.Unix())
+	}
+	if closeIn > 0 {
+		question += fmt.Sprintf("\n-# Closes %s after posting", closeIn)
+	}
+	if len(tags) > 0 {
+		question += "\n-# " + formatTags(tags)
+	}
 
 	// Send poll message
 	_, err = b.s.SendMessageComplex(e.ChannelID, api.SendMessageData{
 		Content: "## Preview\n" + question,
-		Components: discord.Components(
-			components...,
-		),
+		Components: append(buttonRows(components...), buttonRows(controls...)...),
 	})
 	if err != nil {
 		b.respondError(e, "Failed to announce question")
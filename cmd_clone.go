@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+)
+
+func (b *Bot) handleCloneCommand(e *gateway.InteractionCreateEvent) error {
+	data := e.Data.(*discord.CommandInteraction)
+
+	// Check permissions
+	member, err := b.s.Member(e.GuildID, e.Member.User.ID)
+	if err != nil {
+		b.respondError(e, "Failed to get member")
+		return err
+	}
+	perms, err := b.s.Permissions(e.ChannelID, member.User.ID)
+	if err != nil || !perms.Has(discord.PermissionManageChannels) {
+		b.respondError(e, "You need to have the Manage Channels permission to clone questions")
+		return err
+	}
+
+	qIds := parseIds(data.Options.Find("question_ids").String())
+	if len(qIds) == 0 {
+		b.respondError(e, "No question ID provided")
+		return nil
+	}
+
+	target := e.GuildID
+	if opt := data.Options.Find("guild"); opt.Name != "" {
+		id, err := strconv.ParseInt(strings.TrimSpace(opt.String()), 10, 64)
+		if err != nil || id <= 0 {
+			b.respondError(e, "Invalid server ID")
+			return nil
+		}
+		target = discord.GuildID(id)
+	}
+	if target != e.GuildID {
+		perms, err := b.guildPermissions(target, e.Member.User.ID)
+		if err != nil || !perms.Has(discord.PermissionManageChannels) {
+			b.respondError(e, "You need to have the Manage Channels permission in the target server, and the bot needs to be in it")
+			return err
+		}
+	}
+
+	questions := make([]*Question, 0, len(qIds))
+	for _, qId := range qIds {
+		q, err := b.store.Question(qId)
+		if err != nil || q.GuildID != int64(e.GuildID) {
+			b.respondError(e, fmt.Sprintf("Q#%d is not your poll!", qId))
+			return nil
+		}
+		questions = append(questions, q)
+	}
+
+	var result strings.Builder
+	for _, q := range questions {
+		c, err := b.store.CreateQuestion(q.clone(int64(target), int64(e.Member.User.ID)))
+		if err != nil {
+			b.respondError(e, "Failed to clone question")
+			return err
+		}
+		result.WriteString(fmt.Sprintf("Q#%d → Q#%d\n", q.QID, c.QID))
+	}
+
+	where := "here"
+	if target != e.GuildID {
+		where = fmt.Sprintf("into server %d", target)
+	}
+	b.respond(e, fmt.Sprintf("Cloned %s:\n%s", where, result.String()), discord.EphemeralMessage)
+	return nil
+}
+
+// clone copies the question, its options, answers and props into a new open question
+// of the guild. The close time is not copied, as it belongs to the original's run.
+func (q *Question) clone(guildID int64, creatorID int64) *Question {
+	c := copyQuestion(q)
+	c.QID = 0
+	c.GuildID = guildID
+	c.CreatorID = creatorID
+	c.IsClosed = false
+	c.CloseAt = nil
+	c.SourceID = q.QID
+	return c
+}
+
+// guildPermissions is what the user may do across the guild, before channel overwrites.
+func (b *Bot) guildPermissions(guildID discord.GuildID, userID discord.UserID) (discord.Permissions, error) {
+	guild, err := b.s.Guild(guildID)
+	if err != nil {
+		return 0, err
+	}
+	member, err := b.s.Member(guildID, userID)
+	if err != nil {
+		return 0, err
+	}
+	roles, err := b.s.Roles(guildID)
+	if err != nil {
+		return 0, err
+	}
+	return discord.CalcOverrides(*guild, discord.Channel{}, *member, roles), nil
+}
@@ -2,10 +2,13 @@ package main
 
 import (
 	"fmt"
-	// "strings"
+	"log"
+	"strings"
 
+	"github.com/diamondburned/arikawa/v3/api"
 	"github.com/diamondburned/arikawa/v3/discord"
 	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
 )
 
 
@@ -31,40 +34,128 @@ func (b *Bot) handleCloseCommand(e *gateway.InteractionCreateEvent) error {
 		return nil
 	}
 
-	// inClause := strings.Trim(strings.Join(strings.Fields(fmt.Sprint(qIds)), ","), "[]")
+	return b.closeQuestions(e, qIds)
+}
 
-    // // Close the questions
-    // r, err := b.db.Exec("UPDATE questions SET is_closed = TRUE WHERE id IN (?) AND guild_id = ? AND is_closed = FALSE", inClause, e.GuildID.String())
-    // if err != nil {
-    //     b.respondError(e, "Failed to close questions")
-    //     return err
-    // }
-
-	// rows, err := r.RowsAffected()
-	// if err != nil {
-	// 	b.respondError(e, "Failed to close questions")
-	// 	return err
-	// }
-
-	count := 0
+// closeQuestions closes the questions of the guild and updates their posts.
+func (b *Bot) closeQuestions(e *gateway.InteractionCreateEvent, qIds []int64) error {
+	var closedIds []int64
 	for _, qId := range qIds {
-		r, err := b.db.Exec("UPDATE questions SET is_closed = TRUE WHERE id = ? AND guild_id = ? AND is_closed = FALSE", qId, e.GuildID.String())
+		closed, err := b.store.CloseQuestion(qId, int64(e.GuildID))
 		if err != nil {
 			b.respondError(e, "Failed to close questions")
 			return err
 		}
+		if closed {
+			closedIds = append(closedIds, qId)
+		}
+	}
+
+    b.respond(e, fmt.Sprintf("Closed %d/%d questions", len(closedIds), len(qIds)), discord.EphemeralMessage)
+
+	// Editing the posts can take longer than Discord waits for the response
+	go func() {
+		for _, qId := range closedIds {
+			b.closePosts(qId)
+		}
+	}()
+
+	return nil
+}
 
-		rows, err := r.RowsAffected()
+// closePosts edits every message the question was posted in once it is closed:
+// the buttons are disabled, the correct options turn green and the final counts are shown.
+func (b *Bot) closePosts(qId int64) {
+	q, err := b.store.Question(qId)
+	if err != nil {
+		log.Printf("Failed to get question %d: %v", qId, err)
+		return
+	}
+	posts, err := b.store.Posts(qId)
+	if err != nil || len(posts) == 0 {
 		if err != nil {
-			b.respondError(e, "Failed to close questions")
-			return err
+			log.Printf("Failed to get posts of question %d: %v", qId, err)
+		}
+		return
+	}
+	responses, err := b.store.Responses(qId)
+	if err != nil {
+		log.Printf("Failed to get responses of question %d: %v", qId, err)
+		return
+	}
+
+	msgData, err := b.preparePost(q)
+	if err != nil {
+		log.Printf("Failed to prepare question %d: %v", qId, err)
+		return
+	}
+	var content strings.Builder
+	content.WriteString(msgData.Content)
+	content.WriteString("\n🔒 **Closed**\n")
+	writeFinalCounts(&content, q, responses)
+
+	for _, p := range posts {
+		_, err := b.s.EditMessageComplex(discord.ChannelID(p.ChannelID), discord.MessageID(p.MessageID), api.EditMessageData{
+			Content:    option.NewNullableString(truncate(content.String(), 2000)),
+			Components: &msgData.Components,
+		})
+		if err != nil {
+			log.Printf("Failed to update post %d of question %d: %v", p.MessageID, qId, err)
 		}
-		if rows == 1 {
-			count++
+	}
+}
+
+// writeFinalCounts summarizes the answers to a closed question for its posts.
+func writeFinalCounts(result *strings.Builder, q *Question, responses []Response) {
+	total := len(responses)
+	if total == 0 {
+		result.WriteString("*No responses*")
+		return
+	}
+
+	correct := 0
+	for _, r := range responses {
+		if q.isCorrectResponse(&r, false) {
+			correct++
 		}
 	}
 
-    b.respond(e, fmt.Sprintf("Closed %d/%d questions", count, len(qIds)), discord.EphemeralMessage)
+	switch q.Kind {
+	case KindRank:
+		ranking, points := q.bordaRanking(responses, false)
+		for i, c := range ranking {
+			result.WriteString(fmt.Sprintf("%d. **%s**: %d pts\n", i+1, q.Options[c].Label, points[c]))
+		}
+	case KindNumber:
+		if t, ok := q.target(); ok {
+			result.WriteString(fmt.Sprintf("🎯 Answer: `%s` (%s)\n", t.Value, t.describe()))
+		}
+		if values := numericValues(responses, false); len(values) > 0 {
+			result.WriteString(fmt.Sprintf("📈 Mean: %.4g, Median: %.4g\n", mean(values), median(values)))
+		}
+	case KindText:
+		if len(q.Accepted) > 0 {
+			result.WriteString(fmt.Sprintf("🎯 Answer: `%s`\n", q.Accepted[0].Value))
+		}
+	default:
+		counts := make(map[int]int)
+		for _, r := range responses {
+			for _, c := range r.Choices {
+				counts[c]++
+			}
+		}
+		for i, opt := range q.Options {
+			mark := "▫️"
+			if opt.IsCorrect && !q.IsSurvey {
+				mark = "✅"
+			}
+			result.WriteString(fmt.Sprintf("%s **%s**: %d (%.1f%%)\n", mark, opt.Label, counts[i], float64(counts[i])*100/float64(total)))
+		}
+	}
 
-	return nil
-}
\ No newline at end of file
+	if q.IsSurvey {
+		result.WriteString(fmt.Sprintf("-# %d responses", total))
+	} else {
+		result.WriteString(fmt.Sprintf("-# %d/%d correct", correct, total))
+	}
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+func (b *Bot) handleEditCommand(e *gateway.InteractionCreateEvent) error {
+	data := e.Data.(*discord.CommandInteraction)
+	questionID, err := data.Options[0].IntValue()
+	if err != nil {
+		b.respondError(e, "Invalid question ID")
+		return err
+	}
+
+	// Check permissions
+	member, err := b.s.Member(e.GuildID, e.Member.User.ID)
+	if err != nil {
+		b.respondError(e, "Failed to get member")
+		return err
+	}
+	perms, err := b.s.Permissions(e.ChannelID, member.User.ID)
+	if err != nil || !perms.Has(discord.PermissionManageChannels) {
+		b.respondError(e, "You need to have the Manage Channels permission to edit questions")
+		return err
+	}
+
+	q, err := b.store.Question(questionID)
+	if err != nil || q.GuildID != int64(e.GuildID) {
+		b.respondError(e, "Poll not found")
+		return err
+	}
+
+	return b.s.RespondInteraction(e.ID, e.Token, api.InteractionResponse{
+		Type: api.ModalResponse,
+		Data: &api.InteractionResponseData{
+			CustomID:   option.NewNullableString(fmt.Sprintf("editm_%d", q.QID)),
+			Title:      option.NewNullableString(fmt.Sprintf("Edit Q#%d", q.QID)),
+			Components: discord.ComponentsPtr(q.editInputs()...),
+		},
+	})
+}
+
+// editInputs are the fields of the edit form, prefilled with the question. Which ones
+// are shown depends on the kind: ranking questions are answered by the order of their
+// options, and surveys have no answer at all.
+func (q *Question) editInputs() []discord.Component {
+	inputs := []discord.Component{
+		&discord.TextInputComponent{
+			CustomID:     "question",
+			Style:        discord.TextInputParagraphStyle,
+			Label:        "Question",
+			LengthLimits: [2]int{1, 2000},
+			Required:     true,
+			Value:        q.Question,
+		},
+	}
+
+	if !q.isTyped() {
+		label := "Options, one per line"
+		if q.Kind == KindRank && !q.IsSurvey {
+			label = "Options, one per line, in the correct order"
+		}
+		labels := make([]string, len(q.Options))
+		for i, opt := range q.Options {
+			labels[i] = opt.Label
+		}
+		inputs = append(inputs, &discord.TextInputComponent{
+			CustomID:     "options",
+			Style:        discord.TextInputParagraphStyle,
+			Label:        label,
+			LengthLimits: [2]int{1, 4000},
+			Required:     true,
+			Value:        strings.Join(labels, "\n"),
+		})
+	}
+
+	switch {
+	case q.IsSurvey, q.Kind == KindRank:
+	case q.isTyped():
+		match := MatchCI
+		if q.Kind == KindNumber {
+			match = MatchNumeric
+		}
+		lines := make([]string, len(q.Accepted))
+		for i, a := range q.Accepted {
+			lines[i] = a.markdown(match)
+		}
+		label := "Accepted answers, one per line"
+		if q.Kind == KindNumber {
+			label = "Target, e.g. 42, [~0.5] 42 or [~5%] 42"
+		}
+		inputs = append(inputs, &discord.TextInputComponent{
+			CustomID:     "answer",
+			Style:        discord.TextInputParagraphStyle,
+			Label:        label,
+			LengthLimits: [2]int{1, 4000},
+			Required:     true,
+			Value:        strings.Join(lines, "\n"),
+		})
+	default:
+		inputs = append(inputs, &discord.TextInputComponent{
+			CustomID:     "answer",
+			Style:        discord.TextInputShortStyle,
+			Label:        "Correct option numbers (e.g. 1,3)",
+			LengthLimits: [2]int{1, 100},
+			Required:     true,
+			Value:        strings.Trim(strings.Join(strings.Fields(fmt.Sprint(incremented(q.correctChoices()))), ","), "[]"),
+		})
+	}
+
+	anon := "no"
+	if q.IsAnon {
+		anon = "yes"
+	}
+	inputs = append(inputs, &discord.TextInputComponent{
+		CustomID:     "anon",
+		Style:        discord.TextInputShortStyle,
+		Label:        "Anonymous? (yes or no)",
+		LengthLimits: [2]int{2, 3},
+		Required:     true,
+		Value:        anon,
+	})
+	return inputs
+}
+
+// incremented numbers option indices from 1, as they are shown to users.
+func incremented(choices []int) []int {
+	numbers := make([]int, len(choices))
+	for i, c := range choices {
+		numbers[i] = c + 1
+	}
+	return numbers
+}
+
+// applyEdit reads the submitted edit form into a copy of q.
+func (q *Question) applyEdit(fields discord.ContainerComponents) (*Question, error) {
+	value := func(id string) (string, bool) {
+		input, ok := fields.Find(discord.ComponentID(id)).(*discord.TextInputComponent)
+		if !ok {
+			return "", false
+		}
+		return strings.TrimSpace(input.Value), true
+	}
+
+	edited := copyQuestion(q)
+
+	text, _ := value("question")
+	if text == "" {
+		return nil, fmt.Errorf("please provide a question")
+	}
+	edited.Question = text
+
+	switch anon, _ := value("anon"); strings.ToLower(anon) {
+	case "yes", "y", "true":
+		edited.IsAnon = true
+	case "no", "n", "false":
+		edited.IsAnon = false
+	default:
+		return nil, fmt.Errorf("anonymous should be yes or no, not %q", anon)
+	}
+
+	if options, ok := value("options"); ok {
+		edited.Options = nil
+		for _, line := range strings.Split(options, "\n") {
+			if label := strings.TrimSpace(line); label != "" {
+				opt := QuestionOption{Label: label}
+				// Options keep their emoji while their label stays the same
+				for _, old := range q.Options {
+					if old.Label == label {
+						opt.Emoji = old.Emoji
+						break
+					}
+				}
+				edited.Options = append(edited.Options, opt)
+			}
+		}
+		if len(edited.Options) < 1 || (q.Kind == KindRank && len(edited.Options) < 2) {
+			return nil, fmt.Errorf("please provide more options")
+		}
+		if _, err := edited.optionComponents(); err != nil {
+			return nil, err
+		}
+	}
+
+	answer, ok := value("answer")
+	if !ok {
+		return edited, nil
+	}
+	switch q.Kind {
+	case KindText, KindNumber:
+		match := MatchCI
+		if q.Kind == KindNumber {
+			match = MatchNumeric
+		}
+		edited.Accepted = nil
+		for _, line := range strings.Split(answer, "\n") {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			a, err := parseAcceptedAnswer(line, match, 0)
+			if err != nil {
+				return nil, err
+			}
+			if q.Kind == KindNumber && a.Match != MatchNumeric && a.Match != MatchPercent {
+				return nil, fmt.Errorf("the target of a number question should be a number")
+			}
+			edited.Accepted = append(edited.Accepted, a)
+		}
+		if len(edited.Accepted) == 0 {
+			return nil, fmt.Errorf("please provide an answer")
+		}
+		if q.Kind == KindNumber && len(edited.Accepted) > 1 {
+			return nil, fmt.Errorf("number questions have a single target")
+		}
+	default:
+		numbers := parseIds(answer)
+		if len(numbers) == 0 {
+			return nil, fmt.Errorf("please provide answers as option numbers (e.g. 1,3)")
+		}
+		for i := range edited.Options {
+			edited.Options[i].IsCorrect = false
+		}
+		for _, n := range numbers {
+			if n < 1 || int(n) > len(edited.Options) {
+				return nil, fmt.Errorf("there is no option %d", n)
+			}
+			edited.Options[n-1].IsCorrect = true
+		}
+	}
+	return edited, nil
+}
+
+// editSummary names what an edit changed, for the audit log.
+func editSummary(before *Question, after *Question) string {
+	var changed []string
+	if before.Question != after.Question {
+		changed = append(changed, "question")
+	}
+	if !sameOptions(before.Options, after.Options) {
+		changed = append(changed, "options")
+	}
+	if fmt.Sprint(before.correctChoices(), before.Accepted) != fmt.Sprint(after.correctChoices(), after.Accepted) ||
+		(before.Kind == KindRank && !sameOptions(before.Options, after.Options)) {
+		changed = append(changed, "answer")
+	}
+	if before.IsAnon != after.IsAnon {
+		changed = append(changed, "anon")
+	}
+	return strings.Join(changed, ", ")
+}
+
+func (b *Bot) handleEditSubmit(e *gateway.InteractionCreateEvent, qId int64) error {
+	data := e.Data.(*discord.ModalInteraction)
+
+	q, err := b.store.Question(qId)
+	if err != nil || q.GuildID != int64(e.GuildID) {
+		b.respondError(e, "Poll not found")
+		return err
+	}
+
+	edited, err := q.applyEdit(data.Components)
+	if err != nil {
+		b.respondError(e, fmt.Sprintf("Invalid edit: %v", err))
+		return nil
+	}
+
+	summary := editSummary(q, edited)
+	if summary == "" {
+		b.respond(e, "Nothing changed", discord.EphemeralMessage)
+		return nil
+	}
+
+	err = b.store.UpdateQuestion(edited, &QuestionEdit{EditorID: int64(e.Member.User.ID), Summary: summary})
+	if errors.Is(err, ErrHasResponses) {
+		b.respondError(e, fmt.Sprintf("Q#%d already has responses, so its options can't change. Edit the text, answer or anon flag only, or post a new question.", qId))
+		return nil
+	}
+	if err != nil {
+		b.respondError(e, "Failed to save question")
+		return err
+	}
+
+	b.respond(e, fmt.Sprintf("Updated Q#%d: %s", qId, summary), discord.EphemeralMessage)
+
+	// Editing the posts can take longer than Discord waits for the response
+	go b.refreshPosts(qId)
+	return nil
+}
+
+// refreshPosts edits every message the question was posted in to show it as it is now.
+func (b *Bot) refreshPosts(qId int64) {
+	q, err := b.store.Question(qId)
+	if err != nil {
+		log.Printf("Failed to get question %d: %v", qId, err)
+		return
+	}
+	if q.IsClosed {
+		b.closePosts(qId)
+		return
+	}
+
+	posts, err := b.store.Posts(qId)
+	if err != nil {
+		log.Printf("Failed to get posts of question %d: %v", qId, err)
+		return
+	}
+	msgData, err := b.renderPost(q)
+	if err != nil {
+		log.Printf("Failed to prepare question %d: %v", qId, err)
+		return
+	}
+
+	for _, p := range posts {
+		_, err := b.s.EditMessageComplex(discord.ChannelID(p.ChannelID), discord.MessageID(p.MessageID), api.EditMessageData{
+			Content:    option.NewNullableString(msgData.Content),
+			Components: &msgData.Components,
+		})
+		if err != nil {
+			log.Printf("Failed to update post %d of question %d: %v", p.MessageID, qId, err)
+		}
+	}
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/diamondburned/arikawa/v3/discord"
 	"github.com/diamondburned/arikawa/v3/gateway"
@@ -27,7 +26,7 @@ func (b *Bot) handleListCommand(e *gateway.InteractionCreateEvent) error {
     count := int64(10)
     if opt := data.Options.Find("count"); opt.Name != "" {
         count, err = opt.IntValue()
-        if err != nil {
+        if err != nil || count < 1 || count > 200 {
             b.respondError(e, "Invalid count value")
             return err
         }
@@ -42,53 +41,51 @@ func (b *Bot) handleListCommand(e *gateway.InteractionCreateEvent) error {
         }
     }
 
+    filter, err := b.questionFilter(e, data.Options)
+    if err != nil {
+        b.respondError(e, err.Error())
+        return nil
+    }
+    // Newest first unless asked otherwise
+    filter.NewestFirst = data.Options.Find("order").String() != "oldest"
+
     // Get recent questions
-    rows, err := b.db.Query(`
-        SELECT id, creator_id, question, created_at, is_closed, is_anon
-        FROM questions 
-        WHERE guild_id = ?
-        ORDER BY created_at ASC
-        LIMIT ?`,
-        e.GuildID.String(),
-        count,
-    )
+    questions, err := b.store.RecentQuestions(int64(e.GuildID), filter, int(count))
     if err != nil {
         b.respondError(e, "Failed to get questions")
         return err
     }
-    defer rows.Close()
 
     var result strings.Builder
-    result.WriteString("**Recent Questions**\n")
+    if filter.NewestFirst {
+        result.WriteString("**Recent Questions**\n")
+    } else {
+        result.WriteString("**Oldest Questions**\n")
+    }
     
     i := 1
-    for rows.Next() {
-        var id int64
-        var creatorId int64
-        var question string
-        var creationTime time.Time
-        var isClosed bool
-        var isAnon bool
-        err := rows.Scan(&id, &creatorId, &question, &creationTime, &isClosed, &isAnon)
-        if err != nil {
-            continue
-        }
-
-        if isClosed {
+    for _, q := range questions {
+        if q.IsClosed {
             result.WriteString("\n🔒")
         } else {
             result.WriteString("\n🔓")
         }
-        if isAnon {
+        if q.IsAnon {
             result.WriteString("㊙️")
         }
+        if q.IsSurvey {
+            result.WriteString("📊")
+        }
         
-        if strings.Contains(question, "\n") {
+        if strings.Contains(q.Question, "\n") {
             result.WriteString(fmt.Sprintf("**#%d**\n%s (<@%d> <t:%d:R>)\n", 
-                id, question, creatorId, creationTime.Unix()))
+                q.QID, q.Question, q.CreatorID, q.CreatedAt.Unix()))
         } else {
         result.WriteString(fmt.Sprintf("**#%d**: %s (<@%d> <t:%d:R>)\n", 
-            id, question, creatorId, creationTime.Unix()))
+            q.QID, q.Question, q.CreatorID, q.CreatedAt.Unix()))
+        }
+        if len(q.Tags) > 0 {
+            result.WriteString("-# " + formatTags(q.Tags) + "\n")
         }
         i++
     }
@@ -99,10 +96,63 @@ func (b *Bot) handleListCommand(e *gateway.InteractionCreateEvent) error {
     }
 
     if showToEveryone {
-        b.respond(e, result.String(), 0)
+        b.respondPages(e, result.String(), 0)
     } else {
-        b.respond(e, result.String(), discord.EphemeralMessage)
+        b.respondPages(e, result.String(), discord.EphemeralMessage)
     }
 
     return nil
 }
+
+// questionFilter reads the filter options shared by /list and /analyze. Its errors
+// are meant for the user.
+func (b *Bot) questionFilter(e *gateway.InteractionCreateEvent, options discord.CommandInteractionOptions) (QuestionFilter, error) {
+    var filter QuestionFilter
+    filter.Tags = parseTags(options.Find("tags").String())
+
+    now := b.guildNow(e.GuildID)
+    if opt := options.Find("since"); opt.Name != "" {
+        since, err := parsePast(opt.String(), now)
+        if err != nil {
+            return filter, fmt.Errorf("Invalid since: %v", err)
+        }
+        filter.Since = since
+    }
+    if opt := options.Find("until"); opt.Name != "" {
+        until, err := parsePast(opt.String(), now)
+        if err != nil {
+            return filter, fmt.Errorf("Invalid until: %v", err)
+        }
+        filter.Until = until
+    }
+    if !filter.Since.IsZero() && !filter.Until.IsZero() && !filter.Since.Before(filter.Until) {
+        return filter, fmt.Errorf("since has to be before until")
+    }
+
+    switch options.Find("status").String() {
+    case "open":
+        closed := false
+        filter.Closed = &closed
+    case "closed":
+        closed := true
+        filter.Closed = &closed
+    }
+
+    if opt := options.Find("creator"); opt.Name != "" {
+        id, err := opt.SnowflakeValue()
+        if err != nil {
+            return filter, fmt.Errorf("Invalid creator")
+        }
+        filter.CreatorID = int64(id)
+    }
+
+    if opt := options.Find("anon"); opt.Name != "" {
+        anon, err := opt.BoolValue()
+        if err != nil {
+            return filter, fmt.Errorf("Invalid anon value")
+        }
+        filter.Anon = &anon
+    }
+
+    return filter, nil
+}
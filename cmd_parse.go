@@ -4,7 +4,7 @@ import (
 	"fmt"
 	// "log"
 	"strings"
-	
+	"time"
 
 	"github.com/diamondburned/arikawa/v3/discord"
 	"github.com/diamondburned/arikawa/v3/gateway"
@@ -43,9 +43,9 @@ func (b *Bot) handleParseCommand(e *gateway.InteractionCreateEvent) error {
 
     // log.Printf(def)
 
-    questions, err := parseQuestionMarkdown(data.Resolved.Messages[data.TargetMessageID()].Content)
+    questions, quizzes, err := parseQuestionMarkdown(data.Resolved.Messages[data.TargetMessageID()].Content, b.guildNow(e.GuildID))
     if err != nil {
-        b.respondError(e, "Failed to parse question")
+        b.respondError(e, fmt.Sprintf("Failed to parse question: %v", err))
         return err
     }
 
@@ -55,7 +55,7 @@ func (b *Bot) handleParseCommand(e *gateway.InteractionCreateEvent) error {
         qDraft.CreatorID = int64(e.Member.User.ID)
         qDraft.GuildID = int64(e.GuildID)
 
-        q, err := b.insertQuestion(qDraft)
+        q, err := b.store.CreateQuestion(qDraft)
         if err != nil {
             b.respondError(e, "Failed to insert question")
             return err
@@ -63,33 +63,77 @@ func (b *Bot) handleParseCommand(e *gateway.InteractionCreateEvent) error {
 
         qIds = append(qIds, q.QID)
 
-        if err := b.postQuestion(q.QID, int64(e.ChannelID)); err != nil {
+        if err := b.postQuestion(q.QID, int64(e.GuildID), int64(e.ChannelID), int64(e.Member.User.ID)); err != nil {
             b.respondError(e, "Failed to post question")
             return err
         }
     }
 
-    b.respond(e, "Posted: "+strings.Trim(strings.Join(strings.Fields(fmt.Sprint(qIds)), ","), "[]"), discord.EphemeralMessage)
+    reply := "Posted: "+strings.Trim(strings.Join(strings.Fields(fmt.Sprint(qIds)), ","), "[]")
+
+    // Questions under a heading go into the quiz of that name
+    for _, mq := range quizzes {
+        quiz, err := b.quizByName(int64(e.GuildID), mq.Name, int64(e.Member.User.ID))
+        if err != nil {
+            b.respondError(e, "Failed to create quiz")
+            return err
+        }
+        ids := make([]int64, len(mq.Questions))
+        for i, n := range mq.Questions {
+            ids[i] = qIds[n]
+        }
+        added, err := b.store.AddQuizQuestions(quiz.ID, ids)
+        if err != nil {
+            b.respondError(e, "Failed to add questions to quiz")
+            return err
+        }
+        reply += fmt.Sprintf("\nAdded %d to quiz **%s**", added, quiz.Name)
+    }
+
+    b.respond(e, reply, discord.EphemeralMessage)
 
     return nil
 }
 
-func parseQuestionMarkdown (md string) ([]*Question, error) {
+// markdownQuiz is a heading of an import and the questions under it, by index.
+type markdownQuiz struct {
+    Name      string
+    Questions []int
+}
+
+// quizHeading reads the name of a markdown heading such as "## Week 1".
+func quizHeading(line string) (string, bool) {
+    line = strings.TrimSpace(line)
+    rest := strings.TrimLeft(line, "#")
+    if len(rest) == len(line) || !strings.HasPrefix(rest, " ") {
+        return "", false
+    }
+    name := strings.TrimSpace(rest)
+    return name, name != ""
+}
+
+// parseQuestionMarkdown reads the questions of a message. A heading standing alone,
+// like "# Week 1", puts the questions after it into the quiz of that name.
+// Close times are read relative to now, in its location.
+func parseQuestionMarkdown (md string, now time.Time) ([]*Question, []markdownQuiz, error) {
     // Split into lines
     lines := strings.Split(md, "\n")
     
     if len(lines) == 0 {
-        return nil, fmt.Errorf("empty markdown")
+        return nil, nil, fmt.Errorf("empty markdown")
     }
 
     questions := []*Question{}
+    var quizzes []markdownQuiz
     q := &Question{}
     var inQuestion bool
     var inOptions bool
+    // Default match rule of the accepted answers, set by @[match:rule]
+    match := MatchCI
     // var inProps bool
 
     // Parse lines
-    for _, line := range lines {
+    for i, line := range lines {
         // Skip empty lines
         if strings.TrimSpace(line) == "" {
             inQuestion = false
@@ -98,34 +142,93 @@ func parseQuestionMarkdown (md string) ([]*Question, error) {
             continue
         }
 
+        // A heading with a blank line after it names a quiz rather than starting a question
+        if !inQuestion && (i+1 == len(lines) || strings.TrimSpace(lines[i+1]) == "") {
+            if name, ok := quizHeading(line); ok {
+                quizzes = append(quizzes, markdownQuiz{Name: name})
+                continue
+            }
+        }
+
         // If line starts with "- ", it's an option
         if inQuestion && strings.HasPrefix(strings.TrimSpace(line), "- ") {
             inOptions = true
             option := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "- "))
-            if option != "" {
+            if option != "" && q.isTyped() {
+                // Free-text option lines are accepted answers, the option of a number question is its target
+                rule := match
+                if q.Kind == KindNumber {
+                    rule = MatchNumeric
+                }
+                a, err := parseAcceptedAnswer(option, rule, 0)
+                if err != nil {
+                    return nil, nil, err
+                }
+                q.Accepted = append(q.Accepted, a)
+            } else if option != "" {
+                // Any number of options can be marked correct
                 if strings.HasPrefix(option, "[O]") {
-                    q.Answer = int64(len(q.Options))
-                    q.Options = append(q.Options, option[3:])
+                    q.Options = append(q.Options, QuestionOption{Label: strings.TrimSpace(option[3:]), IsCorrect: true})
                 } else {
-                    q.Options = append(q.Options, option)
+                    q.Options = append(q.Options, QuestionOption{Label: option})
                 }
             }
         } else if inQuestion && !inOptions && strings.HasPrefix(strings.TrimSpace(line), "@[") && strings.HasSuffix(strings.TrimSpace(line), "]"){
             // inProps = true
-            prop := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "- "))
+            prop := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "@["))
             prop = strings.TrimSuffix(prop, "]")
             switch prop {
                 case "anon": {
                     q.IsAnon = true
                 }
+                case "multi": {
+                    q.IsMulti = true
+                }
+                case "survey": {
+                    q.IsSurvey = true
+                }
+                case "text": {
+                    q.Kind = KindText
+                }
+                case "number": {
+                    q.Kind = KindNumber
+                }
+                case "rank": {
+                    q.Kind = KindRank
+                }
+                case "live": {
+                    q.IsLive = true
+                }
+                default: {
+                    if rule, ok := strings.CutPrefix(prop, "match:"); ok {
+                        match = rule
+                    }
+                    if tags, ok := strings.CutPrefix(prop, "tags:"); ok {
+                        q.Tags = parseTags(strings.Join(append(q.Tags, tags), ","))
+                    }
+                    if when, ok := strings.CutPrefix(prop, "close:"); ok {
+                        at, err := parseWhen(when, now)
+                        if err != nil {
+                            return nil, nil, err
+                        }
+                        if !at.After(now) {
+                            return nil, nil, fmt.Errorf("the close time has already passed")
+                        }
+                        q.CloseAt = &at
+                    }
+                }
             }
         } else {
             if inOptions {
-                return nil, fmt.Errorf("question text must be before options")
+                return nil, nil, fmt.Errorf("question text must be before options")
             }
             if !inQuestion {
                 q = new(Question)
+                match = MatchCI
                 questions = append(questions, q)
+                if len(quizzes) > 0 {
+                    quizzes[len(quizzes)-1].Questions = append(quizzes[len(quizzes)-1].Questions, len(questions)-1)
+                }
             }
             // It's part of the question text
             q.Question += line + "\n"
@@ -134,14 +237,50 @@ func parseQuestionMarkdown (md string) ([]*Question, error) {
     }
 
     if len(questions) == 0 {
-        return nil, fmt.Errorf("no question")
+        return nil, nil, fmt.Errorf("no question")
     }
 
     for _, q := range questions {
+        if q.isTyped() {
+            if q.IsMulti {
+                return nil, nil, fmt.Errorf("typed answers cannot be multi-select")
+            }
+            if q.Kind == KindNumber {
+                if len(q.Accepted) > 1 {
+                    return nil, nil, fmt.Errorf("number questions have a single target")
+                }
+                if len(q.Accepted) == 1 && q.Accepted[0].Match != MatchNumeric && q.Accepted[0].Match != MatchPercent {
+                    return nil, nil, fmt.Errorf("the target of a number question must be a number")
+                }
+            }
+            // A free-text question without accepted answers is a survey
+            if len(q.Accepted) == 0 {
+                q.IsSurvey = true
+            } else if q.IsSurvey {
+                return nil, nil, fmt.Errorf("surveys have no correct answer")
+            }
+            continue
+        }
         if len(q.Options) == 0 {
-            return nil, fmt.Errorf("no option")
+            return nil, nil, fmt.Errorf("no option")
+        }
+        // Ranking options are listed in their correct order unless it is a survey
+        if q.Kind == KindRank {
+            if q.IsMulti || len(q.correctChoices()) > 0 {
+                return nil, nil, fmt.Errorf("ranking questions take their order from the options")
+            }
+            if len(q.Options) < 2 {
+                return nil, nil, fmt.Errorf("ranking questions need at least two options")
+            }
+            continue
+        }
+        // A question without [O] markers is a survey
+        if len(q.correctChoices()) == 0 {
+            q.IsSurvey = true
+        } else if q.IsSurvey {
+            return nil, nil, fmt.Errorf("surveys have no correct answer")
         }
     }
 
-    return questions, nil
+    return questions, quizzes, nil
 }
\ No newline at end of file
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseQuestionMarkdownClose(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	for _, c := range []struct {
+		close string
+		want  time.Time
+		ok    bool
+	}{
+		{"2h", now.Add(2 * time.Hour), true},
+		{"18:00", time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC), true},
+		{"2024-04-30 09:00", time.Time{}, false},
+		{"1700000000", time.Time{}, false},
+		{"soon", time.Time{}, false},
+	} {
+		md := "Which one?\n@[close:" + c.close + "]\n- [O] A\n- B"
+		questions, _, err := parseQuestionMarkdown(md, now)
+		if (err == nil) != c.ok {
+			t.Errorf("close %q: %v", c.close, err)
+			continue
+		}
+		if c.ok && (questions[0].CloseAt == nil || !questions[0].CloseAt.Equal(c.want)) {
+			t.Errorf("close %q = %v, want %v", c.close, questions[0].CloseAt, c.want)
+		}
+	}
+}
+
+func TestParseQuestionMarkdown(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	md := strings.Join([]string{
+		"# Week 1",
+		"",
+		"Capital of France?",
+		"@[anon]",
+		"@[tags:geo, Europe]",
+		"- [O] Paris",
+		"- Lyon",
+		"",
+		"Primes?",
+		"@[multi]",
+		"- [O] 2",
+		"- 4",
+		"- [O] 5",
+		"",
+		"## Week 2",
+		"",
+		"Favourite colour?",
+		"- Red",
+		"- Blue",
+		"",
+		"Spell it",
+		"@[text]",
+		"@[match:exact]",
+		"- colour",
+		"- [regex] ^colou?r$",
+		"",
+		"Pi?",
+		"@[number]",
+		"@[live]",
+		"- [~0.01] 3.14",
+		"",
+		"Order these",
+		"@[rank]",
+		"- One",
+		"- Two",
+		"- Three",
+	}, "\n")
+
+	questions, quizzes, err := parseQuestionMarkdown(md, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(questions) != 6 {
+		t.Fatalf("questions = %d", len(questions))
+	}
+	if len(quizzes) != 2 || quizzes[0].Name != "Week 1" || !slices.Equal(quizzes[0].Questions, []int{0, 1}) ||
+		quizzes[1].Name != "Week 2" || !slices.Equal(quizzes[1].Questions, []int{2, 3, 4, 5}) {
+		t.Errorf("quizzes = %+v", quizzes)
+	}
+
+	capital, primes, colour, spell, pi, order := questions[0], questions[1], questions[2], questions[3], questions[4], questions[5]
+	if capital.Question != "Capital of France?\n" || !capital.IsAnon || capital.IsSurvey || !slices.Equal(capital.correctChoices(), []int{0}) || !slices.Equal(capital.Tags, []string{"europe", "geo"}) {
+		t.Errorf("capital = %+v", capital)
+	}
+	if !primes.IsMulti || !slices.Equal(primes.correctChoices(), []int{0, 2}) {
+		t.Errorf("primes = %+v", primes)
+	}
+	if !colour.IsSurvey || len(colour.Options) != 2 {
+		t.Errorf("colour = %+v", colour)
+	}
+	if spell.Kind != KindText || len(spell.Accepted) != 2 || spell.Accepted[0].Match != MatchExact || spell.Accepted[1].Match != MatchRegex {
+		t.Errorf("spell = %+v", spell)
+	}
+	if pi.Kind != KindNumber || !pi.IsLive || len(pi.Accepted) != 1 || pi.Accepted[0].Tolerance != 0.01 {
+		t.Errorf("pi = %+v", pi)
+	}
+	if order.Kind != KindRank || order.IsSurvey || len(order.Options) != 3 {
+		t.Errorf("order = %+v", order)
+	}
+}
+
+func TestParseQuestionMarkdownErrors(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	for _, c := range []struct {
+		name string
+		md   string
+	}{
+		{"empty", ""},
+		{"no option", "Question?"},
+		{"text after options", "Question?\n- A\nMore text"},
+		{"multi text", "Spell it\n@[text]\n@[multi]\n- colour"},
+		{"two targets", "Pi?\n@[number]\n- 3.14\n- 3"},
+		{"target not a number", "Pi?\n@[number]\n- [ci] pi"},
+		{"survey with answer", "Question?\n@[survey]\n- [O] A\n- B"},
+		{"rank with marker", "Order\n@[rank]\n- [O] One\n- Two"},
+		{"rank of one", "Order\n@[rank]\n- One"},
+		{"bad regex", "Spell it\n@[text]\n- [regex] ("},
+	} {
+		if _, _, err := parseQuestionMarkdown(c.md, now); err == nil {
+			t.Errorf("%s: parsed", c.name)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/diamondburned/arikawa/v3/api"
@@ -31,8 +32,34 @@ func (b *Bot) handlePostCommand(e *gateway.InteractionCreateEvent) error {
 		b.respondError(e, "No question ID provided")
 		return nil
 	}
+
+    var closeAt *time.Time
+    if opt := data.Options.Find("close"); opt.Name != "" {
+        now := b.guildNow(e.GuildID)
+        at, err := parseWhen(opt.String(), now)
+        if err != nil {
+            b.respondError(e, fmt.Sprintf("Invalid close time: %v", err))
+            return nil
+        }
+        if !at.After(now) {
+            b.respondError(e, "The close time has already passed")
+            return nil
+        }
+        closeAt = &at
+    }
     
-    err = b.s.RespondInteraction(e.ID, e.Token, api.InteractionResponse{
+    return b.postQuestions(e, qIds, closeAt)
+}
+
+// postQuestions posts the questions in the channel of the interaction, one per second,
+// scheduling them to close at closeAt when it is set.
+func (b *Bot) postQuestions(e *gateway.InteractionCreateEvent, qIds []int64, closeAt *time.Time) error {
+    // Questions of other guilds are never posted
+    if !b.ownsQuestions(e, qIds) {
+        return nil
+    }
+
+    err := b.s.RespondInteraction(e.ID, e.Token, api.InteractionResponse{
 		Type: api.DeferredMessageInteractionWithSource,
         Data: &api.InteractionResponseData{
             Flags: discord.EphemeralMessage,
@@ -43,7 +70,16 @@ func (b *Bot) handlePostCommand(e *gateway.InteractionCreateEvent) error {
     }
 
     for _, qId := range qIds {
-        err = b.postQuestion(qId, int64(e.ChannelID))
+        if closeAt != nil {
+            err = b.store.ScheduleClose(qId, closeAt)
+            if err != nil {
+                _, err = b.s.EditInteractionResponse(e.AppID, e.Token, api.EditInteractionResponseData{
+                    Content: option.NewNullableString("❌Failed to schedule close"),
+                })
+                return err
+            }
+        }
+        err = b.postQuestion(qId, int64(e.GuildID), int64(e.ChannelID), int64(e.Member.User.ID))
         if err != nil {
             _, err = b.s.EditInteractionResponse(e.AppID, e.Token, api.EditInteractionResponseData{
                 Content: option.NewNullableString("❌Failed to post question"),
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+)
+
+func (b *Bot) handleQuizCommand(e *gateway.InteractionCreateEvent) error {
+	data := e.Data.(*discord.CommandInteraction)
+
+	// Check permissions
+	member, err := b.s.Member(e.GuildID, e.Member.User.ID)
+	if err != nil {
+		b.respondError(e, "Failed to get member")
+		return err
+	}
+	perms, err := b.s.Permissions(e.ChannelID, member.User.ID)
+	if err != nil || !perms.Has(discord.PermissionManageChannels) {
+		b.respondError(e, "You need to have the Manage Channels permission to manage quizzes")
+		return err
+	}
+
+	if len(data.Options) == 0 {
+		b.respondError(e, "No subcommand provided")
+		return nil
+	}
+	sub := data.Options[0]
+
+	if sub.Name == "list" {
+		return b.handleQuizList(e)
+	}
+
+	name := strings.TrimSpace(sub.Options.Find("name").String())
+	if name == "" {
+		b.respondError(e, "Please provide a quiz name")
+		return nil
+	}
+
+	if sub.Name == "create" {
+		return b.handleQuizCreate(e, name, sub.Options)
+	}
+
+	quiz, err := b.store.Quiz(int64(e.GuildID), name)
+	if errors.Is(err, ErrNotFound) {
+		b.respondError(e, fmt.Sprintf("There is no quiz named %q", name))
+		return nil
+	}
+	if err != nil {
+		b.respondError(e, "Failed to get quiz")
+		return err
+	}
+
+	if sub.Name == "add" {
+		return b.handleQuizAdd(e, quiz, sub.Options)
+	}
+
+	if len(quiz.QuestionIDs) == 0 {
+		b.respondError(e, fmt.Sprintf("Quiz **%s** has no questions yet", quiz.Name))
+		return nil
+	}
+
+	switch sub.Name {
+	case "post":
+		var closeAt *time.Time
+		if opt := sub.Options.Find("close"); opt.Name != "" {
+			now := b.guildNow(e.GuildID)
+			at, err := parseWhen(opt.String(), now)
+			if err != nil {
+				b.respondError(e, fmt.Sprintf("Invalid close time: %v", err))
+				return nil
+			}
+			if !at.After(now) {
+				b.respondError(e, "The close time has already passed")
+				return nil
+			}
+			closeAt = &at
+		}
+		return b.postQuestions(e, quiz.QuestionIDs, closeAt)
+	case "close":
+		return b.closeQuestions(e, quiz.QuestionIDs)
+	case "analyze":
+		showToEveryone := false
+		if opt := sub.Options.Find("public"); opt.Name != "" {
+			showToEveryone, err = opt.BoolValue()
+			if err != nil {
+				b.respondError(e, "Invalid public value")
+				return err
+			}
+		}
+		firstAttempt := sub.Options.Find("attempt").String() == "first"
+		if err := b.deferResponse(e, analysisFlags(showToEveryone)); err != nil {
+			return err
+		}
+		questions, err := b.guildQuestions(e.GuildID, quiz.QuestionIDs)
+		if err != nil {
+			b.editResponseError(e, err.Error())
+			return nil
+		}
+		b.analyzeQuestions(e, questions, "", firstAttempt)
+		return nil
+	}
+
+	return nil
+}
+
+func (b *Bot) handleQuizCreate(e *gateway.InteractionCreateEvent, name string, options discord.CommandInteractionOptions) error {
+	qIds := parseIds(options.Find("question_ids").String())
+	if !b.ownsQuestions(e, qIds) {
+		return nil
+	}
+
+	quiz := &Quiz{GuildID: int64(e.GuildID), Name: name, CreatorID: int64(e.Member.User.ID)}
+	err := b.store.CreateQuiz(quiz)
+	if errors.Is(err, ErrQuizExists) {
+		b.respondError(e, fmt.Sprintf("There already is a quiz named %q", name))
+		return nil
+	}
+	if err != nil {
+		b.respondError(e, "Failed to create quiz")
+		return err
+	}
+
+	added, err := b.store.AddQuizQuestions(quiz.ID, qIds)
+	if err != nil {
+		b.respondError(e, "Failed to add questions to quiz")
+		return err
+	}
+
+	b.respond(e, fmt.Sprintf("Created quiz **%s** with %d questions", quiz.Name, added), discord.EphemeralMessage)
+	return nil
+}
+
+func (b *Bot) handleQuizAdd(e *gateway.InteractionCreateEvent, quiz *Quiz, options discord.CommandInteractionOptions) error {
+	qIds := parseIds(options.Find("question_ids").String())
+	if len(qIds) == 0 {
+		b.respondError(e, "No question ID provided")
+		return nil
+	}
+	if !b.ownsQuestions(e, qIds) {
+		return nil
+	}
+
+	added, err := b.store.AddQuizQuestions(quiz.ID, qIds)
+	if err != nil {
+		b.respondError(e, "Failed to add questions to quiz")
+		return err
+	}
+
+	b.respond(e, fmt.Sprintf("Added %d/%d questions to quiz **%s**", added, len(qIds), quiz.Name), discord.EphemeralMessage)
+	return nil
+}
+
+func (b *Bot) handleQuizList(e *gateway.InteractionCreateEvent) error {
+	quizzes, err := b.store.Quizzes(int64(e.GuildID))
+	if err != nil {
+		b.respondError(e, "Failed to get quizzes")
+		return err
+	}
+	if len(quizzes) == 0 {
+		b.respond(e, "No quizzes yet", discord.EphemeralMessage)
+		return nil
+	}
+
+	var result strings.Builder
+	result.WriteString("**Quizzes**\n")
+	for _, quiz := range quizzes {
+		ids := strings.Trim(strings.Join(strings.Fields(fmt.Sprint(quiz.QuestionIDs)), ","), "[]")
+		result.WriteString(fmt.Sprintf("**%s**: %d questions (%s)\n", quiz.Name, len(quiz.QuestionIDs), ids))
+	}
+
+	b.respondPages(e, result.String(), discord.EphemeralMessage)
+	return nil
+}
+
+// ownsQuestions checks that every question belongs to the guild, responding with an
+// error if one does not.
+func (b *Bot) ownsQuestions(e *gateway.InteractionCreateEvent, qIds []int64) bool {
+	for _, qId := range qIds {
+		q, err := b.store.Question(qId)
+		if err != nil || q.GuildID != int64(e.GuildID) {
+			b.respondError(e, fmt.Sprintf("Q#%d is not your poll!", qId))
+			return false
+		}
+	}
+	return true
+}
+
+// quizByName returns the quiz of the guild with that name, creating it if needed.
+func (b *Bot) quizByName(guildID int64, name string, creatorID int64) (*Quiz, error) {
+	quiz, err := b.store.Quiz(guildID, name)
+	if !errors.Is(err, ErrNotFound) {
+		return quiz, err
+	}
+
+	quiz = &Quiz{GuildID: guildID, Name: name, CreatorID: creatorID}
+	err = b.store.CreateQuiz(quiz)
+	if errors.Is(err, ErrQuizExists) {
+		return b.store.Quiz(guildID, name)
+	}
+	return quiz, err
+}
@@ -2,10 +2,7 @@ package main
 
 import (
 	"fmt"
-	"log"
-	"strconv"
 	"strings"
-	"time"
 
 	"github.com/diamondburned/arikawa/v3/discord"
 	"github.com/diamondburned/arikawa/v3/gateway"
@@ -40,87 +37,132 @@ func (b *Bot) handleResultCommand(e *gateway.InteractionCreateEvent) error {
 		return err
 	}
 
-	q, err := b.queryQuestion(questionID)
+	q, err := b.store.Question(questionID)
 	if err != nil || q.GuildID != int64(e.GuildID) {
 		b.respondError(e, "Poll not found")
 		return err
 	}
 
-	// Get response counts
-	rows, err := b.db.Query(`
-        SELECT 
-            choice,
-            COUNT(*) as count,
-            GROUP_CONCAT(user_id) as users,
-            GROUP_CONCAT(unixepoch(responded_at)) as responded_ats
-        FROM responses 
-        WHERE question_id = ?
-        GROUP BY choice
-        ORDER BY MIN(responded_at)`,
-		questionID,
-	)
+	var result strings.Builder
+
+	result.WriteString(fmt.Sprintf("**Question**\n%s\n", q.Question))
+	if len(q.Tags) > 0 {
+		result.WriteString("-# " + formatTags(q.Tags) + "\n")
+	}
+	if q.SourceID != 0 {
+		result.WriteString(fmt.Sprintf("-# Cloned from Q#%d\n", q.SourceID))
+	}
+	if correct := q.correctChoices(); len(correct) > 0 {
+		result.WriteString(fmt.Sprintf("**Answer:** %s\n", q.optionLabels(correct)))
+	}
+	if q.Kind == KindRank && !q.IsSurvey {
+		result.WriteString(fmt.Sprintf("**Answer:** %s\n", q.orderLabels(q.correctOrder())))
+	}
+	if t, ok := q.target(); ok {
+		result.WriteString(fmt.Sprintf("**Target:** `%s` (%s)\n", t.Value, t.describe()))
+	} else {
+		for _, a := range q.Accepted {
+			result.WriteString(fmt.Sprintf("**Accepted:** `%s` (%s)\n", a.Value, a.describe()))
+		}
+	}
+	result.WriteString("\n")
+
+	responses, err := b.store.Responses(questionID)
 	if err != nil {
 		b.respondError(e, "Failed to get results")
 		return err
 	}
-	defer rows.Close()
 
-	totalResponses := 0
-	var result strings.Builder
+	var totalResponses int
+	switch q.Kind {
+	case KindText:
+		totalResponses = writeTextResults(&result, q, responses)
+	case KindNumber:
+		totalResponses = writeNumberResults(&result, q, responses)
+	case KindRank:
+		totalResponses = writeRankResults(&result, q, responses)
+	default:
+		totalResponses = writeChoiceResults(&result, q, responses)
+	}
+
+    if totalResponses == 0 {
+        result.WriteString("❌ *No responses*\n")
+    }
+
+	result.WriteString(fmt.Sprintf("\nCreated by <@%d> at: <t:%d> (<t:%d:R>)\n", q.CreatorID, q.CreatedAt.Unix(), q.CreatedAt.Unix()))
+	result.WriteString(fmt.Sprintf("Total responses: %d", totalResponses))
+	if showToEveryone {
+		b.respondPages(e, result.String(), 0)
+	} else {
+		b.respondPages(e, result.String(), discord.EphemeralMessage)
+	}
 
-	result.WriteString(fmt.Sprintf("**Question**\n%s\n\n", q.Question))
-	for rows.Next() {
-		var choice string
-		var respTimes string
-		var count int
-		var users string
-		rows.Scan(&choice, &count, &users, &respTimes)
-
-		respTimesList := strings.Split(respTimes, ",")
-		var respTime []time.Time = make([]time.Time, 0)
-		for _, v := range respTimesList {
-			t, err := strconv.ParseInt(v, 10, 64)
-			if err != nil {
-				log.Printf("Failed to parse response time: %v", err)
+	return nil
+}
+
+
+// writeChoiceResults lists who picked each option and returns the number of responders.
+// Options are listed in the order they were first picked, and a multi-select response
+// is listed under each of its choices. Percentages are of responders, so multi-select
+// options can add up to more than 100%.
+func writeChoiceResults(result *strings.Builder, q *Question, responses []Response) int {
+	var picked []int
+	byChoice := make(map[int][]Response)
+	for _, r := range responses {
+		for _, choice := range r.Choices {
+			if byChoice[choice] == nil {
+				picked = append(picked, choice)
 			}
-			respTime = append(respTime, time.Unix(t, 0))
-			respTimesList = respTimesList[1:]
+			byChoice[choice] = append(byChoice[choice], r)
 		}
+	}
 
-		choiceIdx, _ := strconv.Atoi(choice)
-		optionText := q.Options[choiceIdx]
-		totalResponses += count
+	for _, choice := range picked {
+		if choice < 0 || choice >= len(q.Options) {
+			continue
+		}
+		opt := q.Options[choice]
 
-		if q.Answer != -1 && choiceIdx == int(q.Answer) {
+		if opt.IsCorrect {
 			result.WriteString("✅")
 		}
-		result.WriteString(fmt.Sprintf("**Option:** %s (%.1f%%)\n", optionText, float64(count)*100/float64(totalResponses)))
-		for i, userID := range strings.Split(users, ",") {
-			t := respTime[0]
-			respTime = respTime[1:]
+		result.WriteString(fmt.Sprintf("**Option:** %s (%.1f%%)\n", opt.Label, float64(len(byChoice[choice]))*100/float64(len(responses))))
+		for i, r := range byChoice[choice] {
 			if q.IsAnon {
-				result.WriteString(fmt.Sprintf("%d. `anon` (<t:%d:R>)\n", i+1, t.Unix()))
+				result.WriteString(fmt.Sprintf("%d. `anon` (<t:%d:R>)", i+1, r.RespondedAt.Unix()))
 			} else {
-				result.WriteString(fmt.Sprintf("%d. <@%s> (<t:%d:R>)\n", i+1, userID, t.Unix()))
+				result.WriteString(fmt.Sprintf("%d. <@%d> (<t:%d:R>)", i+1, r.UserID, r.RespondedAt.Unix()))
 			}
-			if result.Len() > 1850 {
-				result.WriteString(fmt.Sprintf("*And %d more...*\n", count - 1 - i))
-				break
+			if r.Changes > 0 {
+				result.WriteString(fmt.Sprintf(" ✏️ changed %dx, first: %s", r.Changes, q.optionLabels(r.FirstChoices)))
 			}
+			result.WriteString("\n")
 		}
+		// A blank line lets long results turn the page between options
+		result.WriteString("\n")
 	}
+	return len(responses)
+}
 
-    if totalResponses == 0 {
-        result.WriteString("❌ *No responses*\n")
-    }
-
-	result.WriteString(fmt.Sprintf("\nCreated by <@%d> at: <t:%d> (<t:%d:R>)\n", q.CreatorID, q.CreatedAt.Unix(), q.CreatedAt.Unix()))
-	result.WriteString(fmt.Sprintf("Total responses: %d", totalResponses))
-	if showToEveryone {
-		b.respond(e, result.String(), 0)
-	} else {
-		b.respond(e, result.String(), discord.EphemeralMessage)
+// writeTextResults lists every typed answer, graded, and returns the number of answers.
+func writeTextResults(result *strings.Builder, q *Question, responses []Response) int {
+	for i, r := range responses {
+		if !q.IsSurvey {
+			if q.isCorrectResponse(&r, false) {
+				result.WriteString("✅")
+			} else {
+				result.WriteString("❌")
+			}
+		}
+		if q.IsAnon {
+			result.WriteString(fmt.Sprintf("%d. `anon`: `%s` (<t:%d:R>)", i+1, r.Value, r.RespondedAt.Unix()))
+		} else {
+			result.WriteString(fmt.Sprintf("%d. <@%d>: `%s` (<t:%d:R>)", i+1, r.UserID, r.Value, r.RespondedAt.Unix()))
+		}
+		if r.Changes > 0 {
+			result.WriteString(fmt.Sprintf(" ✏️ changed %dx, first: `%s`", r.Changes, r.FirstValue))
+		}
+		result.WriteString("\n")
 	}
-
-	return nil
+	return len(responses)
 }
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+)
+
+func (b *Bot) handleScheduleCommand(e *gateway.InteractionCreateEvent) error {
+	data := e.Data.(*discord.CommandInteraction)
+
+	// Check permissions
+	member, err := b.s.Member(e.GuildID, e.Member.User.ID)
+	if err != nil {
+		b.respondError(e, "Failed to get member")
+		return err
+	}
+	perms, err := b.s.Permissions(e.ChannelID, member.User.ID)
+	if err != nil || !perms.Has(discord.PermissionManageChannels) {
+		b.respondError(e, "You need to have the Manage Channels permission to schedule questions")
+		return err
+	}
+
+	if len(data.Options) == 0 {
+		b.respondError(e, "No subcommand provided")
+		return nil
+	}
+	sub := data.Options[0]
+
+	switch sub.Name {
+	case "add":
+		return b.handleScheduleAdd(e, sub.Options)
+	case "list":
+		return b.handleScheduleList(e)
+	case "cancel":
+		return b.handleScheduleCancel(e, sub.Options)
+	case "timezone":
+		return b.handleScheduleTimezone(e, sub.Options)
+	}
+
+	return nil
+}
+
+func (b *Bot) handleScheduleAdd(e *gateway.InteractionCreateEvent, options discord.CommandInteractionOptions) error {
+	qIds := parseIds(options.Find("question_ids").String())
+	if len(qIds) == 0 {
+		b.respondError(e, "No question ID provided")
+		return nil
+	}
+
+	now := b.guildNow(e.GuildID)
+	first, err := parseWhen(options.Find("at").String(), now)
+	if err != nil {
+		b.respondError(e, fmt.Sprintf("Invalid time: %v", err))
+		return nil
+	}
+	if !first.After(now) {
+		b.respondError(e, "The time has already passed")
+		return nil
+	}
+
+	var every time.Duration
+	if opt := options.Find("every"); opt.Name != "" {
+		if every, err = parseInterval(opt.String()); err != nil {
+			b.respondError(e, fmt.Sprintf("Invalid interval: %v", err))
+			return nil
+		}
+	}
+
+	var closeAfter time.Duration
+	if opt := options.Find("close"); opt.Name != "" {
+		if closeAfter, err = parseInterval(opt.String()); err != nil {
+			b.respondError(e, fmt.Sprintf("Invalid close duration: %v", err))
+			return nil
+		}
+	}
+
+	channelID := e.ChannelID
+	if opt := options.Find("channel"); opt.Name != "" {
+		id, err := opt.SnowflakeValue()
+		if err != nil {
+			b.respondError(e, "Invalid channel")
+			return err
+		}
+		channelID = discord.ChannelID(id)
+	}
+
+	for _, qId := range qIds {
+		q, err := b.store.Question(qId)
+		if err != nil || q.GuildID != int64(e.GuildID) {
+			b.respondError(e, fmt.Sprintf("Q#%d is not your poll!", qId))
+			return nil
+		}
+	}
+
+	var result strings.Builder
+	for i, qId := range qIds {
+		p := ScheduledPost{
+			GuildID:    int64(e.GuildID),
+			ChannelID:  int64(channelID),
+			QuestionID: qId,
+			CreatorID:  int64(e.Member.User.ID),
+			PostAt:     spreadTime(first.In(now.Location()), every, i),
+			CloseAfter: closeAfter,
+		}
+		if err := b.store.CreateScheduledPost(&p); err != nil {
+			b.respondError(e, "Failed to schedule post")
+			return err
+		}
+		result.WriteString(fmt.Sprintf("`#%d` Q#%d at <t:%d:f> (<t:%d:R>)\n", p.ID, qId, p.PostAt.Unix(), p.PostAt.Unix()))
+	}
+
+	b.respond(e, fmt.Sprintf("Scheduled in <#%d>:\n%s", channelID, result.String()), discord.EphemeralMessage)
+	return nil
+}
+
+// spreadTime is the time of the i-th post of a series starting at first. Whole days are
+// added on the calendar, so a daily 09:00 stays at 09:00 across daylight saving changes.
+func spreadTime(first time.Time, every time.Duration, i int) time.Time {
+	day := 24 * time.Hour
+	if every > 0 && every%day == 0 {
+		return first.AddDate(0, 0, i*int(every/day)).UTC()
+	}
+	return first.Add(time.Duration(i) * every).UTC()
+}
+
+func (b *Bot) handleScheduleList(e *gateway.InteractionCreateEvent) error {
+	posts, err := b.store.ScheduledPosts(int64(e.GuildID))
+	if err != nil {
+		b.respondError(e, "Failed to get scheduled posts")
+		return err
+	}
+	if len(posts) == 0 {
+		b.respond(e, "Nothing scheduled", discord.EphemeralMessage)
+		return nil
+	}
+
+	tz, err := b.store.GuildTimezone(int64(e.GuildID))
+	if err != nil {
+		b.respondError(e, "Failed to get timezone")
+		return err
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("**Scheduled posts** (%s)\n", tz))
+	for _, p := range posts {
+		result.WriteString(fmt.Sprintf("`#%d` Q#%d in <#%d> at <t:%d:f> (<t:%d:R>)", p.ID, p.QuestionID, p.ChannelID, p.PostAt.Unix(), p.PostAt.Unix()))
+		if p.CloseAfter > 0 {
+			result.WriteString(fmt.Sprintf(", closes after %s", p.CloseAfter))
+		}
+		result.WriteString("\n")
+	}
+
+	b.respondPages(e, result.String(), discord.EphemeralMessage)
+	return nil
+}
+
+func (b *Bot) handleScheduleCancel(e *gateway.InteractionCreateEvent, options discord.CommandInteractionOptions) error {
+	ids := parseIds(options.Find("schedule_ids").String())
+	if len(ids) == 0 {
+		b.respondError(e, "No schedule ID provided")
+		return nil
+	}
+
+	count := 0
+	for _, id := range ids {
+		deleted, err := b.store.DeleteScheduledPost(id, int64(e.GuildID))
+		if err != nil {
+			b.respondError(e, "Failed to cancel scheduled posts")
+			return err
+		}
+		if deleted {
+			count++
+		}
+	}
+
+	b.respond(e, fmt.Sprintf("Cancelled %d/%d scheduled posts", count, len(ids)), discord.EphemeralMessage)
+	return nil
+}
+
+func (b *Bot) handleScheduleTimezone(e *gateway.InteractionCreateEvent, options discord.CommandInteractionOptions) error {
+	tz := strings.TrimSpace(options.Find("timezone").String())
+	if _, err := time.LoadLocation(tz); err != nil || tz == "" || tz == "Local" {
+		b.respondError(e, fmt.Sprintf("Unknown timezone %q, use a name like Europe/Berlin", tz))
+		return nil
+	}
+
+	if err := b.store.SetGuildTimezone(int64(e.GuildID), tz); err != nil {
+		b.respondError(e, "Failed to save timezone")
+		return err
+	}
+
+	b.respond(e, fmt.Sprintf("Times are now read in %s", tz), discord.EphemeralMessage)
+	return nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSpreadTime(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip(err)
+	}
+	// Daylight saving starts in Paris on 2024-03-31
+	first := time.Date(2024, 3, 30, 9, 0, 0, 0, paris)
+	for _, c := range []struct {
+		name  string
+		every time.Duration
+		i     int
+		want  time.Time
+	}{
+		{"first", 24 * time.Hour, 0, first},
+		{"daily across DST", 24 * time.Hour, 2, time.Date(2024, 4, 1, 9, 0, 0, 0, paris)},
+		{"weekly", 7 * 24 * time.Hour, 1, time.Date(2024, 4, 6, 9, 0, 0, 0, paris)},
+		{"hourly across DST", time.Hour, 24, first.Add(24 * time.Hour)},
+		{"no interval", 0, 3, first},
+	} {
+		got := spreadTime(first, c.every, c.i)
+		if !got.Equal(c.want) || got.Location() != time.UTC {
+			t.Errorf("%s: spreadTime = %v, want %v", c.name, got, c.want.UTC())
+		}
+	}
+}
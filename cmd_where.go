@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+)
+
+func (b *Bot) handleWhereCommand(e *gateway.InteractionCreateEvent) error {
+	data := e.Data.(*discord.CommandInteraction)
+	questionID, err := data.Options[0].IntValue()
+	if err != nil {
+		b.respondError(e, "Invalid question ID")
+		return err
+	}
+
+	// Check permissions
+	member, err := b.s.Member(e.GuildID, e.Member.User.ID)
+	if err != nil {
+		b.respondError(e, "Failed to get member")
+		return err
+	}
+	perms, err := b.s.Permissions(e.ChannelID, member.User.ID)
+	if err != nil || !perms.Has(discord.PermissionManageChannels) {
+		b.respondError(e, "You need to have the Manage Channels permission to find polls")
+		return err
+	}
+
+	q, err := b.store.Question(questionID)
+	if err != nil || q.GuildID != int64(e.GuildID) {
+		b.respondError(e, "Poll not found")
+		return err
+	}
+
+	posts, err := b.store.Posts(questionID)
+	if err != nil {
+		b.respondError(e, "Failed to get posts")
+		return err
+	}
+	if len(posts) == 0 {
+		b.respond(e, fmt.Sprintf("Q#%d has not been posted yet", questionID), discord.EphemeralMessage)
+		return nil
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("**Q#%d** has been posted %d times\n", questionID, len(posts)))
+	for i, p := range posts {
+		// Posts from before the guild was recorded belong to the question's guild
+		if p.GuildID == 0 {
+			p.GuildID = q.GuildID
+		}
+		result.WriteString(fmt.Sprintf("%d. %s in <#%d> <t:%d:R>", i+1, p.jumpURL(), p.ChannelID, p.PostedAt.Unix()))
+		if p.PostedBy != 0 {
+			result.WriteString(fmt.Sprintf(" by <@%d>", p.PostedBy))
+		}
+		result.WriteString("\n")
+	}
+
+	b.respondPages(e, result.String(), discord.EphemeralMessage)
+	return nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+)
+
+// Discord limits on message components
+const (
+	maxRows          = 5
+	maxRowButtons    = 5
+	maxButtonLabel   = 80
+	maxSelectOptions = 25
+	maxSelectLabel   = 100
+)
+
+// buttonRows lays buttons out left to right, starting a new action row every five buttons.
+func buttonRows(buttons ...discord.InteractiveComponent) discord.ContainerComponents {
+	var rows discord.ContainerComponents
+	for start := 0; start < len(buttons); start += maxRowButtons {
+		end := min(start+maxRowButtons, len(buttons))
+		row := discord.ActionRowComponent(buttons[start:end])
+		rows = append(rows, &row)
+	}
+	return rows
+}
+
+// truncate shortens s to at most n characters.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// usesSelectMenu reports whether the options do not fit on buttons, either because
+// there are too many of them or because a label is too long for a button.
+func (q *Question) usesSelectMenu(rows int) bool {
+	if len(q.Options) > rows*maxRowButtons {
+		return true
+	}
+	for _, opt := range q.Options {
+		if len([]rune(opt.Label)) > maxButtonLabel {
+			return true
+		}
+	}
+	return false
+}
+
+// optionComponents builds the answer components of a choice or ranking question: buttons
+// spread over as many rows as needed, or select menus of up to 25 options each.
+func (q *Question) optionComponents() (discord.ContainerComponents, error) {
+	var extra []discord.InteractiveComponent
+	if q.Kind == KindRank {
+		extra = append(extra, &discord.ButtonComponent{
+			CustomID: discord.ComponentID(fmt.Sprintf("rank_reset_%d", q.QID)),
+			Label:    "Start over",
+			Emoji:    &discord.ComponentEmoji{Name: "↩️"},
+			Style:    discord.SecondaryButtonStyle(),
+			Disabled: q.IsClosed,
+		})
+	}
+
+	rows := maxRows
+	if len(extra) > 0 {
+		rows--
+	}
+	order := q.displayOrder()
+
+	if !q.usesSelectMenu(rows) {
+		buttons := make([]discord.InteractiveComponent, 0, len(order)+len(extra))
+		for _, i := range order {
+			opt := q.Options[i]
+			button := &discord.ButtonComponent{
+				CustomID: discord.ComponentID(fmt.Sprintf("opt_%d_%d", q.QID, i)),
+				Label:    opt.Label,
+				Style:    discord.PrimaryButtonStyle(),
+			}
+			if opt.Emoji != "" {
+				button.Emoji = &discord.ComponentEmoji{Name: opt.Emoji}
+			}
+			// Closed questions keep their buttons, greyed out, with the answer in green
+			if q.IsClosed {
+				button.Disabled = true
+				button.Style = discord.SecondaryButtonStyle()
+				if opt.IsCorrect && !q.IsSurvey {
+					button.Style = discord.SuccessButtonStyle()
+				}
+			}
+			buttons = append(buttons, button)
+		}
+		if len(buttons)+len(extra) <= maxRows*maxRowButtons && len(buttons)%maxRowButtons != 0 {
+			// The extra buttons fit next to the last options
+			return buttonRows(append(buttons, extra...)...), nil
+		}
+		return append(buttonRows(buttons...), buttonRows(extra...)...), nil
+	}
+
+	if len(order) > rows*maxSelectOptions {
+		return nil, fmt.Errorf("too many options: %d", len(order))
+	}
+
+	// Every pick from a menu counts as one click, so menus take a single value
+	placeholder := "Choose an option"
+	if q.IsMulti {
+		placeholder = "Choose an option to select or deselect it"
+	}
+	if q.Kind == KindRank {
+		placeholder = "Choose the next option"
+	}
+
+	var components discord.ContainerComponents
+	for start := 0; start < len(order); start += maxSelectOptions {
+		end := min(start+maxSelectOptions, len(order))
+		menu := &discord.StringSelectComponent{
+			CustomID:    discord.ComponentID(fmt.Sprintf("sel_%d_%d", q.QID, start/maxSelectOptions)),
+			Placeholder: placeholder,
+			ValueLimits: [2]int{1, 1},
+			Disabled:    q.IsClosed,
+		}
+		if len(order) > maxSelectOptions {
+			menu.Placeholder = fmt.Sprintf("%s (%d-%d)", placeholder, start+1, end)
+		}
+		for _, i := range order[start:end] {
+			opt := q.Options[i]
+			selectOption := discord.SelectOption{
+				Label: truncate(opt.Label, maxSelectLabel),
+				Value: strconv.Itoa(i),
+			}
+			if opt.Emoji != "" {
+				selectOption.Emoji = &discord.ComponentEmoji{Name: opt.Emoji}
+			}
+			menu.Options = append(menu.Options, selectOption)
+		}
+		components = append(components, &discord.ActionRowComponent{menu})
+	}
+	return append(components, buttonRows(extra...)...), nil
+}
+
+func (b *Bot) handleSelect(e *gateway.InteractionCreateEvent) error {
+	data := e.Data.(*discord.StringSelectInteraction)
+
+	if strings.HasPrefix(string(data.CustomID), "sel_") {
+		qIdStr, _, _ := strings.Cut(strings.TrimPrefix(string(data.CustomID), "sel_"), "_")
+		qId, err := strconv.ParseInt(qIdStr, 10, 64)
+		if err != nil {
+			return err
+		}
+		if len(data.Values) != 1 {
+			return fmt.Errorf("invalid selection")
+		}
+		choice, err := strconv.Atoi(data.Values[0])
+		if err != nil {
+			return err
+		}
+
+		return b.recordChoice(e, qId, choice)
+	}
+
+	return nil
+}
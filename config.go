@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config is read from the environment (and .env) at startup.
+type Config struct {
+	Token string
+
+	// DatabaseURL selects PostgreSQL when set; the SQLite settings are ignored then.
+	DatabaseURL string
+
+	DBPath        string
+	DBWAL         bool
+	DBBusyTimeout time.Duration
+	DBForeignKeys bool
+}
+
+func loadConfig() (*Config, error) {
+	c := &Config{
+		Token:         os.Getenv("BOT_TOKEN"),
+		DatabaseURL:   os.Getenv("DATABASE_URL"),
+		DBPath:        "poll.db",
+		DBWAL:         true,
+		DBBusyTimeout: 5 * time.Second,
+		DBForeignKeys: true,
+	}
+
+	if c.Token == "" {
+		return nil, fmt.Errorf("BOT_TOKEN environment variable is required")
+	}
+
+	if v := os.Getenv("DB_PATH"); v != "" {
+		c.DBPath = v
+	}
+
+	var err error
+	if v := os.Getenv("DB_WAL"); v != "" {
+		if c.DBWAL, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid DB_WAL: %w", err)
+		}
+	}
+	if v := os.Getenv("DB_BUSY_TIMEOUT"); v != "" {
+		if c.DBBusyTimeout, err = time.ParseDuration(v); err != nil {
+			return nil, fmt.Errorf("invalid DB_BUSY_TIMEOUT: %w", err)
+		}
+	}
+	if v := os.Getenv("DB_FOREIGN_KEYS"); v != "" {
+		if c.DBForeignKeys, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid DB_FOREIGN_KEYS: %w", err)
+		}
+	}
+
+	return c, nil
+}
+
+// sqliteDSN builds the SQLite connection string. The pragmas are passed in the DSN
+// so the driver applies them to every pooled connection, not just the first one.
+// Transactions take the write lock when they begin: most of them read before they
+// write, and in WAL mode a read lock that later needs to write fails with SQLITE_BUSY
+// at once instead of waiting out busy_timeout.
+func (c *Config) sqliteDSN() string {
+	pragmas := url.Values{}
+	pragmas.Add("_txlock", "immediate")
+	pragmas.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", c.DBBusyTimeout.Milliseconds()))
+	if c.DBWAL {
+		pragmas.Add("_pragma", "journal_mode(WAL)")
+	}
+	if c.DBForeignKeys {
+		pragmas.Add("_pragma", "foreign_keys(1)")
+	} else {
+		pragmas.Add("_pragma", "foreign_keys(0)")
+	}
+
+	return "file:" + c.DBPath + "?" + pragmas.Encode()
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+)
+
+// dialect holds what differs between the SQL backends. Queries are written
+// for SQLite with ? placeholders and adapted on the way to the driver.
+type dialect struct {
+	name   string
+	driver string
+	// numbered placeholders ($1, $2...) instead of ?
+	numbered bool
+	// schema rewrites applied to migration statements
+	schema *strings.Replacer
+}
+
+var (
+	sqliteDialect = &dialect{
+		name:   "sqlite",
+		driver: "sqlite",
+		schema: strings.NewReplacer(),
+	}
+	postgresDialect = &dialect{
+		name:     "postgres",
+		driver:   "postgres",
+		numbered: true,
+		schema: strings.NewReplacer(
+			"INTEGER PRIMARY KEY AUTOINCREMENT", "BIGSERIAL PRIMARY KEY",
+			"INTEGER", "BIGINT",
+			"CURRENT_TIMESTAMP", "(now() AT TIME ZONE 'utc')",
+		),
+	}
+)
+
+func (d *dialect) rebind(query string) string {
+	if !d.numbered {
+		return query
+	}
+
+	var sb strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			sb.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// dbConn is a *sql.DB that rebinds every query for its dialect.
+type dbConn struct {
+	*sql.DB
+	dialect *dialect
+}
+
+func (c *dbConn) Exec(query string, args ...any) (sql.Result, error) {
+	return c.DB.Exec(c.dialect.rebind(query), args...)
+}
+
+func (c *dbConn) Query(query string, args ...any) (*sql.Rows, error) {
+	return c.DB.Query(c.dialect.rebind(query), args...)
+}
+
+func (c *dbConn) QueryRow(query string, args ...any) *sql.Row {
+	return c.DB.QueryRow(c.dialect.rebind(query), args...)
+}
+
+func (c *dbConn) Begin() (*dbTx, error) {
+	tx, err := c.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &dbTx{Tx: tx, dialect: c.dialect}, nil
+}
+
+// dbTx is a *sql.Tx that rebinds every query for its dialect.
+type dbTx struct {
+	*sql.Tx
+	dialect *dialect
+}
+
+func (t *dbTx) Exec(query string, args ...any) (sql.Result, error) {
+	return t.Tx.Exec(t.dialect.rebind(query), args...)
+}
+
+func (t *dbTx) Query(query string, args ...any) (*sql.Rows, error) {
+	return t.Tx.Query(t.dialect.rebind(query), args...)
+}
+
+func (t *dbTx) QueryRow(query string, args ...any) *sql.Row {
+	return t.Tx.QueryRow(t.dialect.rebind(query), args...)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// liveUpdateDelay is how long a click waits for others before the posts of a live
+// question are edited, so a burst of clicks costs a single edit.
+const liveUpdateDelay = 3 * time.Second
+
+// liveUpdater debounces the edits of live posts. The first touch of a question schedules
+// an update after the delay, and further touches until then are covered by it.
+type liveUpdater struct {
+	mu      sync.Mutex
+	pending map[int64]bool
+	delay   time.Duration
+	update  func(qId int64)
+}
+
+func newLiveUpdater(delay time.Duration, update func(qId int64)) *liveUpdater {
+	return &liveUpdater{
+		pending: make(map[int64]bool),
+		delay:   delay,
+		update:  update,
+	}
+}
+
+// touch asks for the posts of the question to be updated soon.
+func (u *liveUpdater) touch(qId int64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.pending[qId] {
+		return
+	}
+	u.pending[qId] = true
+	time.AfterFunc(u.delay, func() {
+		u.mu.Lock()
+		delete(u.pending, qId)
+		u.mu.Unlock()
+
+		u.update(qId)
+	})
+}
+
+// renderPost is preparePost with the running counts of live questions added.
+func (b *Bot) renderPost(q *Question) (api.SendMessageData, error) {
+	msgData, err := b.preparePost(q)
+	if err != nil || !q.IsLive || q.IsClosed {
+		return msgData, err
+	}
+
+	responses, err := b.store.Responses(q.QID)
+	if err != nil {
+		return msgData, err
+	}
+
+	var content strings.Builder
+	content.WriteString(msgData.Content)
+	content.WriteString("\n")
+	writeLiveCounts(&content, q, responses)
+	msgData.Content = truncate(content.String(), 2000)
+	return msgData, nil
+}
+
+// updateLivePosts edits every post of a live question to show the current counts.
+func (b *Bot) updateLivePosts(qId int64) {
+	q, err := b.store.Question(qId)
+	if err != nil {
+		log.Printf("Failed to get question %d: %v", qId, err)
+		return
+	}
+	// Closing replaces the counts with the final ones
+	if !q.IsLive || q.IsClosed {
+		return
+	}
+
+	posts, err := b.store.Posts(qId)
+	if err != nil {
+		log.Printf("Failed to get posts of question %d: %v", qId, err)
+		return
+	}
+	msgData, err := b.renderPost(q)
+	if err != nil {
+		log.Printf("Failed to prepare question %d: %v", qId, err)
+		return
+	}
+
+	for _, p := range posts {
+		_, err := b.s.EditMessageComplex(discord.ChannelID(p.ChannelID), discord.MessageID(p.MessageID), api.EditMessageData{
+			Content: option.NewNullableString(msgData.Content),
+		})
+		if err != nil {
+			log.Printf("Failed to update post %d of question %d: %v", p.MessageID, qId, err)
+		}
+	}
+}
+
+// progressBar draws count out of total as a bar of ten blocks.
+func progressBar(count int, total int) string {
+	const width = 10
+	filled := 0
+	if total > 0 {
+		filled = (count*width + total/2) / total
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+// writeLiveCounts shows how the answers to an open question are spread, without
+// revealing which of them are correct.
+func writeLiveCounts(result *strings.Builder, q *Question, responses []Response) {
+	total := len(responses)
+
+	switch q.Kind {
+	case KindRank:
+		if total > 0 {
+			ranking, points := q.bordaRanking(responses, false)
+			for i, c := range ranking {
+				result.WriteString(fmt.Sprintf("%d. **%s**: %d pts\n", i+1, q.Options[c].Label, points[c]))
+			}
+		}
+	case KindText, KindNumber:
+	default:
+		counts := make(map[int]int)
+		for _, r := range responses {
+			for _, c := range r.Choices {
+				counts[c]++
+			}
+		}
+		for i, opt := range q.Options {
+			percentage := 0.0
+			if total > 0 {
+				percentage = float64(counts[i]) * 100 / float64(total)
+			}
+			result.WriteString(fmt.Sprintf("`%s` **%s**: %d (%.0f%%)\n", progressBar(counts[i], total), opt.Label, counts[i], percentage))
+		}
+	}
+
+	result.WriteString(fmt.Sprintf("-# 📶 %d responses", total))
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// migration is a single schema step. Steps are applied in order, each inside
+// its own transaction, and the schema_version row is bumped in the same
+// transaction so a failed step leaves the database untouched.
+type migration struct {
+	version int
+	name    string
+	up      func(tx *dbTx) error
+}
+
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "initial tables",
+		up: func(tx *dbTx) error {
+			return execAll(tx,
+				`CREATE TABLE IF NOT EXISTS questions (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					creator_id TEXT NOT NULL,
+					guild_id TEXT NOT NULL,
+					question TEXT NOT NULL,
+					options TEXT NOT NULL,
+					answer_id INTEGER NOT NULL,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					is_closed BOOLEAN DEFAULT FALSE
+				)`,
+				`CREATE TABLE IF NOT EXISTS responses (
+					question_id INTEGER,
+					user_id TEXT NOT NULL,
+					choice INTEGER NOT NULL,
+					responded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					FOREIGN KEY(question_id) REFERENCES questions(id),
+					PRIMARY KEY (question_id, user_id)
+				)`,
+			)
+		},
+	},
+	{
+		version: 2,
+		name:    "questions.is_anon",
+		up: func(tx *dbTx) error {
+			// Databases created while is_anon was in the CREATE TABLE already have it
+			exists, err := columnExists(tx, "questions", "is_anon")
+			if err != nil || exists {
+				return err
+			}
+			return execAll(tx, `ALTER TABLE questions ADD COLUMN is_anon BOOLEAN DEFAULT FALSE`)
+		},
+	},
+	{
+		version: 3,
+		name:    "question_options",
+		up: func(tx *dbTx) error {
+			err := execAll(tx,
+				`CREATE TABLE question_options (
+					question_id INTEGER NOT NULL,
+					position INTEGER NOT NULL,
+					label TEXT NOT NULL,
+					emoji TEXT NOT NULL DEFAULT '',
+					is_correct BOOLEAN NOT NULL DEFAULT FALSE,
+					FOREIGN KEY(question_id) REFERENCES questions(id),
+					PRIMARY KEY (question_id, position)
+				)`,
+			)
+			if err != nil {
+				return err
+			}
+
+			// Move the pipe-joined options over, then drop the old columns
+			rows, err := tx.Query(`SELECT id, options, answer_id FROM questions`)
+			if err != nil {
+				return err
+			}
+			type legacyQuestion struct {
+				id      int64
+				options string
+				answer  int
+			}
+			var legacy []legacyQuestion
+			for rows.Next() {
+				var lq legacyQuestion
+				if err := rows.Scan(&lq.id, &lq.options, &lq.answer); err != nil {
+					rows.Close()
+					return err
+				}
+				legacy = append(legacy, lq)
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			for _, lq := range legacy {
+				if lq.options == "" {
+					continue
+				}
+				for i, label := range strings.Split(lq.options, "|") {
+					_, err := tx.Exec(
+						`INSERT INTO question_options (question_id, position, label, is_correct) VALUES (?, ?, ?, ?)`,
+						lq.id, i, label, i == lq.answer,
+					)
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+			return execAll(tx,
+				`ALTER TABLE questions DROP COLUMN options`,
+				`ALTER TABLE questions DROP COLUMN answer_id`,
+			)
+		},
+	},
+	{
+		version: 4,
+		name:    "drafts",
+		up: func(tx *dbTx) error {
+			return execAll(tx,
+				`CREATE TABLE drafts (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					creator_id TEXT NOT NULL,
+					guild_id TEXT NOT NULL,
+					channel_id TEXT NOT NULL,
+					question TEXT NOT NULL,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					expires_at TIMESTAMP NOT NULL
+				)`,
+				`CREATE INDEX drafts_expires_at ON drafts (expires_at)`,
+			)
+		},
+	},
+	{
+		version: 5,
+		name:    "response_events",
+		up: func(tx *dbTx) error {
+			return execAll(tx,
+				`CREATE TABLE response_events (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					question_id INTEGER NOT NULL,
+					user_id TEXT NOT NULL,
+					choice INTEGER NOT NULL,
+					responded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					FOREIGN KEY(question_id) REFERENCES questions(id)
+				)`,
+				`CREATE INDEX response_events_question ON response_events (question_id, id)`,
+				// Only the latest choice survived INSERT OR REPLACE, so that becomes the whole history
+				`INSERT INTO response_events (question_id, user_id, choice, responded_at)
+					SELECT question_id, user_id, choice, responded_at FROM responses
+					WHERE question_id IS NOT NULL
+					ORDER BY responded_at`,
+				`DROP TABLE responses`,
+			)
+		},
+	},
+	{
+		version: 6,
+		name:    "multi-select questions",
+		up: func(tx *dbTx) error {
+			return execAll(tx,
+				`ALTER TABLE questions ADD COLUMN is_multi BOOLEAN NOT NULL DEFAULT FALSE`,
+				`ALTER TABLE response_events ADD COLUMN selected BOOLEAN NOT NULL DEFAULT TRUE`,
+			)
+		},
+	},
+	{
+		version: 7,
+		name:    "questions.is_survey",
+		up: func(tx *dbTx) error {
+			return execAll(tx,
+				`ALTER TABLE questions ADD COLUMN is_survey BOOLEAN NOT NULL DEFAULT FALSE`,
+				`UPDATE questions SET is_survey = TRUE WHERE id NOT IN (SELECT question_id FROM question_options WHERE is_correct)`,
+			)
+		},
+	},
+	{
+		version: 8,
+		name:    "free-text questions",
+		up: func(tx *dbTx) error {
+			return execAll(tx,
+				`ALTER TABLE questions ADD COLUMN kind TEXT NOT NULL DEFAULT 'choice'`,
+				`CREATE TABLE accepted_answers (
+					question_id INTEGER NOT NULL,
+					position INTEGER NOT NULL,
+					value TEXT NOT NULL,
+					match_rule TEXT NOT NULL DEFAULT 'exact',
+					tolerance DOUBLE PRECISION NOT NULL DEFAULT 0,
+					FOREIGN KEY(question_id) REFERENCES questions(id),
+					PRIMARY KEY (question_id, position)
+				)`,
+				`ALTER TABLE response_events ADD COLUMN value TEXT NOT NULL DEFAULT ''`,
+			)
+		},
+	},
+	{
+		version: 9,
+		name:    "scheduled close and question_posts",
+		up: func(tx *dbTx) error {
+			return execAll(tx,
+				`ALTER TABLE questions ADD COLUMN close_at TIMESTAMP`,
+				`CREATE INDEX questions_close_at ON questions (close_at)`,
+				`CREATE TABLE question_posts (
+					question_id INTEGER NOT NULL,
+					channel_id TEXT NOT NULL,
+					message_id TEXT NOT NULL,
+					posted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					FOREIGN KEY(question_id) REFERENCES questions(id),
+					PRIMARY KEY (question_id, message_id)
+				)`,
+			)
+		},
+	},
+	{
+		version: 10,
+		name:    "scheduled_posts and guild_settings",
+		up: func(tx *dbTx) error {
+			return execAll(tx,
+				`CREATE TABLE scheduled_posts (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					guild_id TEXT NOT NULL,
+					channel_id TEXT NOT NULL,
+					question_id INTEGER NOT NULL,
+					creator_id TEXT NOT NULL,
+					post_at TIMESTAMP NOT NULL,
+					close_after INTEGER NOT NULL DEFAULT 0,
+					FOREIGN KEY(question_id) REFERENCES questions(id)
+				)`,
+				`CREATE INDEX scheduled_posts_post_at ON scheduled_posts (post_at)`,
+				`CREATE TABLE guild_settings (
+					guild_id TEXT PRIMARY KEY,
+					timezone TEXT NOT NULL DEFAULT 'UTC'
+				)`,
+			)
+		},
+	},
+	{
+		version: 11,
+		name:    "questions.is_live",
+		up: func(tx *dbTx) error {
+			return execAll(tx, `ALTER TABLE questions ADD COLUMN is_live BOOLEAN NOT NULL DEFAULT FALSE`)
+		},
+	},
+	{
+		version: 12,
+		name:    "question_posts.guild_id and posted_by",
+		up: func(tx *dbTx) error {
+			// Posts recorded so far were made in the guild of their question
+			return execAll(tx,
+				`ALTER TABLE question_posts ADD COLUMN guild_id TEXT NOT NULL DEFAULT ''`,
+				`ALTER TABLE question_posts ADD COLUMN posted_by TEXT NOT NULL DEFAULT ''`,
+				`UPDATE question_posts SET guild_id = (SELECT guild_id FROM questions WHERE questions.id = question_posts.question_id)`,
+			)
+		},
+	},
+	{
+		version: 13,
+		name:    "question_edits",
+		up: func(tx *dbTx) error {
+			return execAll(tx,
+				`CREATE TABLE question_edits (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					question_id INTEGER NOT NULL,
+					editor_id TEXT NOT NULL,
+					summary TEXT NOT NULL,
+					edited_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					FOREIGN KEY(question_id) REFERENCES questions(id)
+				)`,
+				`CREATE INDEX question_edits_question_id ON question_edits (question_id)`,
+			)
+		},
+	},
+	{
+		version: 14,
+		name:    "questions.source_question_id",
+		up: func(tx *dbTx) error {
+			return execAll(tx, `ALTER TABLE questions ADD COLUMN source_question_id INTEGER REFERENCES questions(id)`)
+		},
+	},
+	{
+		version: 15,
+		name:    "quizzes",
+		up: func(tx *dbTx) error {
+			return execAll(tx,
+				`CREATE TABLE quizzes (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					guild_id TEXT NOT NULL,
+					name TEXT NOT NULL,
+					creator_id TEXT NOT NULL,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					UNIQUE (guild_id, name)
+				)`,
+				`CREATE TABLE quiz_questions (
+					quiz_id INTEGER NOT NULL,
+					question_id INTEGER NOT NULL,
+					position INTEGER NOT NULL,
+					FOREIGN KEY(quiz_id) REFERENCES quizzes(id),
+					FOREIGN KEY(question_id) REFERENCES questions(id),
+					PRIMARY KEY(quiz_id, question_id)
+				)`,
+			)
+		},
+	},
+	{
+		version: 16,
+		name:    "question_tags",
+		up: func(tx *dbTx) error {
+			return execAll(tx,
+				`CREATE TABLE question_tags (
+					question_id INTEGER NOT NULL,
+					tag TEXT NOT NULL,
+					FOREIGN KEY(question_id) REFERENCES questions(id),
+					PRIMARY KEY(question_id, tag)
+				)`,
+				`CREATE INDEX question_tags_tag ON question_tags (tag)`,
+			)
+		},
+	},
+	{
+		version: 17,
+		name:    "drafts.close_in",
+		up: func(tx *dbTx) error {
+			return execAll(tx, `ALTER TABLE drafts ADD COLUMN close_in INTEGER NOT NULL DEFAULT 0`)
+		},
+	},
+	{
+		version: 18,
+		name:    "scheduled_posts.attempts",
+		up: func(tx *dbTx) error {
+			return execAll(tx, `ALTER TABLE scheduled_posts ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0`)
+		},
+	},
+}
+
+// migrate brings the database up to the latest schema version known to this binary.
+func migrate(db *dbConn) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_version (version INTEGER NOT NULL)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_version: %w", err)
+	}
+
+	current, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+
+	latest := migrations[len(migrations)-1].version
+	if current > latest {
+		return fmt.Errorf("database schema version %d is newer than this binary supports (%d)", current, latest)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := applyMigration(db, m); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.name, err)
+		}
+		log.Printf("Applied migration %d: %s", m.version, m.name)
+	}
+
+	return nil
+}
+
+func schemaVersion(db *dbConn) (int, error) {
+	var version int
+	err := db.QueryRow(`SELECT version FROM schema_version`).Scan(&version)
+	if err == sql.ErrNoRows {
+		if _, err := db.Exec(`INSERT INTO schema_version (version) VALUES (0)`); err != nil {
+			return 0, fmt.Errorf("failed to init schema_version: %w", err)
+		}
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema_version: %w", err)
+	}
+	return version, nil
+}
+
+func applyMigration(db *dbConn, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := m.up(tx); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE schema_version SET version = ?`, m.version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// execAll runs schema statements, adapting them to the dialect first.
+func execAll(tx *dbTx, queries ...string) error {
+	for _, query := range queries {
+		if _, err := tx.Exec(tx.dialect.schema.Replace(query)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func columnExists(tx *dbTx, table string, column string) (bool, error) {
+	query := "SELECT name FROM pragma_table_info(?)"
+	if tx.dialect == postgresDialect {
+		query = "SELECT column_name FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = ?"
+	}
+	rows, err := tx.Query(query, table)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
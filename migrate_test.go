@@ -0,0 +1,102 @@
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestMigrateLegacy upgrades a database in the layout the bot used before migrations,
+// with pipe-joined options and a single stored choice per user.
+func TestMigrateLegacy(t *testing.T) {
+	c := &Config{DBPath: filepath.Join(t.TempDir(), "qanda.db"), DBForeignKeys: true}
+	db, err := sql.Open(sqliteDialect.driver, c.sqliteDSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		`CREATE TABLE questions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			creator_id TEXT NOT NULL,
+			guild_id TEXT NOT NULL,
+			question TEXT NOT NULL,
+			options TEXT NOT NULL,
+			answer_id INTEGER NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			is_closed BOOLEAN DEFAULT FALSE
+		)`,
+		`CREATE TABLE responses (
+			question_id INTEGER,
+			user_id TEXT NOT NULL,
+			choice INTEGER NOT NULL,
+			responded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY(question_id) REFERENCES questions(id),
+			PRIMARY KEY (question_id, user_id)
+		)`,
+		`INSERT INTO questions (id, creator_id, guild_id, question, options, answer_id, created_at, is_closed)
+			VALUES (1, '1', '10', 'Capital?', 'Paris|Lyon|Nice', 0, '2024-01-01 10:00:00', TRUE)`,
+		`INSERT INTO questions (id, creator_id, guild_id, question, options, answer_id, created_at)
+			VALUES (2, '1', '10', 'Largest?', 'Mars|Jupiter', 1, '2024-01-02 10:00:00')`,
+		`INSERT INTO responses (question_id, user_id, choice, responded_at) VALUES (1, '100', 2, '2024-01-01 10:05:00')`,
+		`INSERT INTO responses (question_id, user_id, choice, responded_at) VALUES (1, '101', 0, '2024-01-01 10:01:00')`,
+		`INSERT INTO responses (question_id, user_id, choice, responded_at) VALUES (2, '100', 1, '2024-01-02 10:01:00')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	s, err := openSQLStore(sqliteDialect, c.sqliteDSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	for _, want := range []struct {
+		id        int64
+		labels    []string
+		correct   []int
+		closed    bool
+		responses []int64
+		choices   []int
+	}{
+		{1, []string{"Paris", "Lyon", "Nice"}, []int{0}, true, []int64{101, 100}, []int{0, 2}},
+		{2, []string{"Mars", "Jupiter"}, []int{1}, false, []int64{100}, []int{1}},
+	} {
+		q, err := s.Question(want.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var labels []string
+		for _, opt := range q.Options {
+			labels = append(labels, opt.Label)
+		}
+		if !slices.Equal(labels, want.labels) || !slices.Equal(q.correctChoices(), want.correct) {
+			t.Errorf("Q#%d options = %+v", want.id, q.Options)
+		}
+		if q.IsClosed != want.closed || q.IsAnon || q.IsMulti || q.IsSurvey || q.Kind != KindChoice || q.GuildID != 10 || q.CreatorID != 1 {
+			t.Errorf("Q#%d = %+v", want.id, q)
+		}
+
+		responses, err := s.Responses(want.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(responses) != len(want.responses) {
+			t.Fatalf("Q#%d responses = %+v", want.id, responses)
+		}
+		for i, r := range responses {
+			if r.UserID != want.responses[i] || !slices.Equal(r.Choices, []int{want.choices[i]}) || r.Changes != 0 {
+				t.Errorf("Q#%d response %d = %+v", want.id, i, r)
+			}
+		}
+	}
+
+	// New questions carry on after the legacy IDs
+	q := mustCreate(t, s, &Question{CreatorID: 1, GuildID: 10, Question: "New", Options: []QuestionOption{{Label: "A", IsCorrect: true}}})
+	if q.QID != 3 {
+		t.Errorf("new question ID = %d", q.QID)
+	}
+}
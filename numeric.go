@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Grades of numeric answers
+const (
+	GradeWrong = iota
+	GradeClose
+	GradeCorrect
+)
+
+// gradeEmoji marks a grade in results.
+var gradeEmoji = map[int]string{
+	GradeWrong:   "❌",
+	GradeClose:   "🟡",
+	GradeCorrect: "✅",
+}
+
+// target is the accepted answer of a numeric question, if it has one.
+func (q *Question) target() (*AcceptedAnswer, bool) {
+	if q.Kind != KindNumber || len(q.Accepted) == 0 {
+		return nil, false
+	}
+	return &q.Accepted[0], true
+}
+
+// grade rates a numeric answer. Answers within the tolerance are correct, answers
+// within twice the tolerance (or 10% of the target without one) are close.
+func (q *Question) grade(value string) int {
+	t, ok := q.target()
+	if !ok {
+		return GradeWrong
+	}
+	diff, ok := t.distance(value)
+	if !ok {
+		return GradeWrong
+	}
+
+	allowed := t.allowed()
+	if diff <= allowed {
+		return GradeCorrect
+	}
+	closeBy := 2 * allowed
+	if allowed == 0 {
+		want, _ := strconv.ParseFloat(t.Value, 64)
+		closeBy = math.Abs(want) / 10
+	}
+	if diff <= closeBy {
+		return GradeClose
+	}
+	return GradeWrong
+}
+
+// parseNumber reads a typed number. Infinities and NaN are not numbers anyone answers
+// with, and they would break the statistics, so they are rejected.
+func parseNumber(s string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, fmt.Errorf("%q is not a finite number", s)
+	}
+	return v, nil
+}
+
+// numericValues parses the answers of the responses, skipping anything that is not a number.
+func numericValues(responses []Response, firstAttempt bool) []float64 {
+	var values []float64
+	for _, r := range responses {
+		value := r.Value
+		if firstAttempt {
+			value = r.FirstValue
+		}
+		if v, err := parseNumber(value); err == nil {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func median(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// writeNumberResults lists every numeric answer with its grade and distance from the target,
+// and returns the number of answers.
+func writeNumberResults(result *strings.Builder, q *Question, responses []Response) int {
+	t, hasTarget := q.target()
+	for i, r := range responses {
+		if hasTarget {
+			result.WriteString(gradeEmoji[q.grade(r.Value)])
+		}
+		if q.IsAnon {
+			result.WriteString(fmt.Sprintf("%d. `anon`: `%s`", i+1, r.Value))
+		} else {
+			result.WriteString(fmt.Sprintf("%d. <@%d>: `%s`", i+1, r.UserID, r.Value))
+		}
+		if hasTarget {
+			if diff, ok := t.distance(r.Value); ok {
+				result.WriteString(fmt.Sprintf(" (off by %g)", diff))
+			}
+		}
+		result.WriteString(fmt.Sprintf(" (<t:%d:R>)", r.RespondedAt.Unix()))
+		if r.Changes > 0 {
+			result.WriteString(fmt.Sprintf(" ✏️ changed %dx, first: `%s`", r.Changes, r.FirstValue))
+		}
+		result.WriteString("\n")
+	}
+	return len(responses)
+}
+
+// writeNumberDistribution shows the mean, median and spread of numeric answers,
+// bucketed into up to five ranges between the lowest and highest answer.
+func writeNumberDistribution(result *strings.Builder, responses []Response, firstAttempt bool) {
+	values := numericValues(responses, firstAttempt)
+	if len(values) == 0 {
+		return
+	}
+
+	result.WriteString(fmt.Sprintf("📈 **Mean**: %.4g, **Median**: %.4g\n", mean(values), median(values)))
+
+	low, high := slices.Min(values), slices.Max(values)
+	if low == high {
+		result.WriteString(fmt.Sprintf("📊 `%g`: %d (100.0%%)\n", low, len(values)))
+		return
+	}
+	// Halving and dividing before subtracting keeps answers near the float limits from overflowing
+	const buckets = 5
+	width := high/buckets - low/buckets
+	var counts [buckets]int
+	for _, v := range values {
+		i := int((v/2 - low/2) / (high/2 - low/2) * buckets)
+		counts[min(max(i, 0), buckets-1)]++
+	}
+	for i, count := range counts {
+		if count == 0 {
+			continue
+		}
+		from := low + float64(i)*width
+		percentage := float64(count) * 100 / float64(len(values))
+		result.WriteString(fmt.Sprintf("📊 `%.4g – %.4g`: %d (%.1f%%)\n", from, from+width, count, percentage))
+	}
+}
+
+// writeClosestUsers ranks the users of a numeric question by how far they were from the target.
+func writeClosestUsers(result *strings.Builder, q *Question, responses []Response, firstAttempt bool) {
+	t, ok := q.target()
+	if !ok || q.IsAnon {
+		return
+	}
+
+	type closeness struct {
+		userID int64
+		value  string
+		diff   float64
+	}
+	var ranks []closeness
+	for _, r := range responses {
+		value := r.Value
+		if firstAttempt {
+			value = r.FirstValue
+		}
+		if diff, ok := t.distance(value); ok {
+			ranks = append(ranks, closeness{r.UserID, value, diff})
+		}
+	}
+	slices.SortStableFunc(ranks, func(a, b closeness) int {
+		switch {
+		case a.diff < b.diff:
+			return -1
+		case a.diff > b.diff:
+			return 1
+		}
+		return 0
+	})
+
+	result.WriteString("🎯 **Closest**\n")
+	for i := 0; i < len(ranks) && i < 5; i++ {
+		result.WriteString(fmt.Sprintf("%d. %s <@%d>: `%s` (off by %g)\n", i+1, gradeEmoji[q.grade(ranks[i].value)], ranks[i].userID, ranks[i].value, ranks[i].diff))
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseNumber(t *testing.T) {
+	for _, c := range []struct {
+		in   string
+		want float64
+		ok   bool
+	}{
+		{"42", 42, true},
+		{" -3.5 ", -3.5, true},
+		{"1e308", 1e308, true},
+		{"Inf", 0, false},
+		{"-inf", 0, false},
+		{"NaN", 0, false},
+		{"1e309", 0, false},
+		{"12abc", 0, false},
+		{"", 0, false},
+	} {
+		got, err := parseNumber(c.in)
+		if (err == nil) != c.ok || got != c.want {
+			t.Errorf("parseNumber(%q) = %v, %v", c.in, got, err)
+		}
+	}
+}
+
+func TestWriteNumberDistribution(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		values []string
+		want   []string
+	}{
+		{"spread", []string{"1", "2", "3", "4", "11"}, []string{"**Median**: 3", "`1 – 3`: 2 (40.0%)", "`9 – 11`: 1 (20.0%)"}},
+		{"same", []string{"7", "7"}, []string{"`7`: 2 (100.0%)"}},
+		{"infinity", []string{"5", "Inf"}, []string{"`5`: 1 (100.0%)"}},
+		{"nan", []string{"NaN", "3"}, []string{"`3`: 1 (100.0%)"}},
+		{"float limits", []string{"1e308", "-1e308"}, []string{"`-1e+308 – -6e+307`: 1 (50.0%)", "`6e+307 – 1e+308`: 1 (50.0%)"}},
+		{"not numbers", []string{"abc", "Inf"}, nil},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			responses := make([]Response, len(c.values))
+			for i, v := range c.values {
+				responses[i] = Response{UserID: int64(i), Value: v}
+			}
+			var result strings.Builder
+			writeNumberDistribution(&result, responses, false)
+			for _, want := range c.want {
+				if !strings.Contains(result.String(), want) {
+					t.Errorf("missing %q in\n%s", want, result.String())
+				}
+			}
+			if c.want == nil && result.Len() > 0 {
+				t.Errorf("got\n%s", result.String())
+			}
+		})
+	}
+}
+
+func TestValidateNumericAnswer(t *testing.T) {
+	for _, c := range []struct {
+		answer AcceptedAnswer
+		ok     bool
+	}{
+		{AcceptedAnswer{Value: "3.14", Match: MatchNumeric, Tolerance: 0.01}, true},
+		{AcceptedAnswer{Value: "100", Match: MatchPercent, Tolerance: 5}, true},
+		{AcceptedAnswer{Value: "Inf", Match: MatchNumeric}, false},
+		{AcceptedAnswer{Value: "NaN", Match: MatchPercent}, false},
+		{AcceptedAnswer{Value: "abc", Match: MatchNumeric}, false},
+		{AcceptedAnswer{Value: "3", Match: MatchNumeric, Tolerance: -1}, false},
+	} {
+		if err := c.answer.validate(); (err == nil) != c.ok {
+			t.Errorf("validate(%+v) = %v", c.answer, err)
+		}
+	}
+}
+
+func TestNumericMatches(t *testing.T) {
+	exact := AcceptedAnswer{Value: "3.14", Match: MatchNumeric}
+	near := AcceptedAnswer{Value: "3.14", Match: MatchNumeric, Tolerance: 0.01}
+	percent := AcceptedAnswer{Value: "200", Match: MatchPercent, Tolerance: 5}
+	for _, c := range []struct {
+		answer AcceptedAnswer
+		value  string
+		want   bool
+	}{
+		{exact, "3.14", true},
+		{exact, " 3.140 ", true},
+		{exact, "3.15", false},
+		{near, "3.149", true},
+		{near, "3.16", false},
+		{percent, "210", true},
+		{percent, "189", false},
+		{percent, "Inf", false},
+		{near, "NaN", false},
+		{near, "pi", false},
+	} {
+		if got := c.answer.matches(c.value); got != c.want {
+			t.Errorf("%+v matches %q = %v, want %v", c.answer, c.value, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// maxPageLength keeps a page within Discord's 2000 character message limit.
+const maxPageLength = 1900
+
+// pageTTL is how long the buttons of a paginated response keep working.
+const pageTTL = 15 * time.Minute
+
+// pageSession is a response split into pages, which only its user can turn.
+type pageSession struct {
+	userID    discord.UserID
+	pages     []string
+	expiresAt time.Time
+}
+
+// pager keeps the pages of long responses in memory until they expire.
+type pager struct {
+	mu       sync.Mutex
+	sessions map[int64]*pageSession
+}
+
+func newPager() *pager {
+	return &pager{sessions: make(map[int64]*pageSession)}
+}
+
+// open stores the pages under a fresh session ID, dropping the sessions that expired.
+// IDs are random so buttons left over from before a restart cannot turn another user's pages.
+func (p *pager) open(userID discord.UserID, pages []string) int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for id, s := range p.sessions {
+		if now.After(s.expiresAt) {
+			delete(p.sessions, id)
+		}
+	}
+
+	id := rand.Int64()
+	for _, taken := p.sessions[id]; taken; _, taken = p.sessions[id] {
+		id = rand.Int64()
+	}
+	p.sessions[id] = &pageSession{userID: userID, pages: pages, expiresAt: now.Add(pageTTL)}
+	return id
+}
+
+// session returns the pages stored under id, unless they expired.
+func (p *pager) session(id int64) (*pageSession, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s, ok := p.sessions[id]
+	if !ok || time.Now().After(s.expiresAt) {
+		return nil, false
+	}
+	return s, true
+}
+
+// splitPages cuts text into pages of at most limit characters. Pages end between
+// blocks separated by a blank line, such as the questions of a report, so a block
+// is only split across pages, between lines, when it does not fit on a page alone.
+// A line too long for a page of its own is cut.
+func splitPages(text string, limit int) []string {
+	var pages []string
+	var page strings.Builder
+	size := 0
+	add := func(s string) {
+		n := utf8.RuneCountInString(s)
+		if size > 0 && size+n > limit {
+			pages = append(pages, strings.TrimRight(page.String(), "\n"))
+			page.Reset()
+			size = 0
+		}
+		page.WriteString(s)
+		size += n
+	}
+
+	for _, block := range strings.SplitAfter(strings.Trim(text, "\n"), "\n\n") {
+		if utf8.RuneCountInString(block) <= limit {
+			add(block)
+			continue
+		}
+		for _, line := range strings.SplitAfter(block, "\n") {
+			add(truncate(line, limit))
+		}
+	}
+	pages = append(pages, strings.TrimRight(page.String(), "\n"))
+	return pages
+}
+
+// pageButtons are the navigation buttons shown under page n of a session.
+func pageButtons(sessionID int64, n int, total int) discord.ContainerComponents {
+	return buttonRows(
+		&discord.ButtonComponent{
+			CustomID: discord.ComponentID(fmt.Sprintf("page_%d_%d", sessionID, n-1)),
+			Label:    "◀",
+			Style:    discord.SecondaryButtonStyle(),
+			Disabled: n == 0,
+		},
+		&discord.ButtonComponent{
+			CustomID: discord.ComponentID(fmt.Sprintf("page_%d_at", sessionID)),
+			Label:    fmt.Sprintf("%d/%d", n+1, total),
+			Style:    discord.SecondaryButtonStyle(),
+			Disabled: true,
+		},
+		&discord.ButtonComponent{
+			CustomID: discord.ComponentID(fmt.Sprintf("page_%d_%d", sessionID, n+1)),
+			Label:    "▶",
+			Style:    discord.SecondaryButtonStyle(),
+			Disabled: n == total-1,
+		},
+	)
+}
+
+// respondPages responds with the content, split into pages with buttons to turn them
+// when it does not fit in one message.
+func (b *Bot) respondPages(e *gateway.InteractionCreateEvent, content string, flags discord.MessageFlags) {
+	pages := splitPages(content, maxPageLength)
+	if len(pages) == 1 {
+		b.respond(e, pages[0], flags)
+		return
+	}
+
+	id := b.pages.open(e.SenderID(), pages)
+	components := pageButtons(id, 0, len(pages))
+	err := b.s.RespondInteraction(e.ID, e.Token, api.InteractionResponse{
+		Type: api.MessageInteractionWithSource,
+		Data: &api.InteractionResponseData{
+			Content:    option.NewNullableString(pages[0]),
+			Components: &components,
+			Flags:      flags,
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to respond to interaction: %v", err)
+	}
+}
+
+// editPages fills in a deferred response with the content, paged like respondPages does.
+func (b *Bot) editPages(e *gateway.InteractionCreateEvent, content string) {
+	pages := splitPages(content, maxPageLength)
+	data := api.EditInteractionResponseData{Content: option.NewNullableString(pages[0])}
+	if len(pages) > 1 {
+		components := pageButtons(b.pages.open(e.SenderID(), pages), 0, len(pages))
+		data.Components = &components
+	}
+	if _, err := b.s.EditInteractionResponse(e.AppID, e.Token, data); err != nil {
+		log.Printf("Failed to edit interaction response: %v", err)
+	}
+}
+
+// turnPage shows the page a navigation button points to.
+func (b *Bot) turnPage(e *gateway.InteractionCreateEvent, customID string) error {
+	idStr, nStr, _ := strings.Cut(strings.TrimPrefix(customID, "page_"), "_")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return err
+	}
+	n, err := strconv.Atoi(nStr)
+	if err != nil {
+		return err
+	}
+
+	s, ok := b.pages.session(id)
+	if !ok {
+		b.respondError(e, "These pages have expired, please run the command again")
+		return nil
+	}
+	if s.userID != e.SenderID() {
+		b.respondError(e, "Only the one who ran the command can turn its pages")
+		return nil
+	}
+	if n < 0 || n >= len(s.pages) {
+		return fmt.Errorf("invalid page %d", n)
+	}
+
+	components := pageButtons(id, n, len(s.pages))
+	return b.s.RespondInteraction(e.ID, e.Token, api.InteractionResponse{
+		Type: api.UpdateMessage,
+		Data: &api.InteractionResponseData{
+			Content:    option.NewNullableString(s.pages[n]),
+			Components: &components,
+		},
+	})
+}
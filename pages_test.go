@@ -0,0 +1,48 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitPages(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		text  string
+		limit int
+		want  []string
+	}{
+		{"fits", "short\n", 10, []string{"short"}},
+		{"empty", "", 10, []string{""}},
+		{"between blocks", "aaaa\n\nbbbb\n\ncccc", 10, []string{"aaaa", "bbbb\n\ncccc"}},
+		{"block too long", "aaaa\nbbbb\ncccc", 10, []string{"aaaa\nbbbb", "cccc"}},
+		{"line too long", "abcdefghijklmnop", 10, []string{"abcdefghi…"}},
+		{"counts runes", "ééééé\n\nééééé", 7, []string{"ééééé", "ééééé"}},
+	} {
+		if got := splitPages(c.text, c.limit); !slices.Equal(got, c.want) {
+			t.Errorf("%s: splitPages = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestSplitPagesLimit(t *testing.T) {
+	var report strings.Builder
+	for i := range 200 {
+		report.WriteString(strings.Repeat("question ", i%40) + "\n")
+		report.WriteString(strings.Repeat("✅ option\n", i%7) + "\n")
+	}
+	pages := splitPages(report.String(), maxPageLength)
+	if len(pages) < 2 {
+		t.Fatalf("pages = %d", len(pages))
+	}
+	for i, page := range pages {
+		if n := utf8.RuneCountInString(page); n > maxPageLength || n == 0 {
+			t.Errorf("page %d has %d characters", i, n)
+		}
+	}
+	if got := strings.Join(pages, "\n\n"); strings.Count(got, "question") != strings.Count(report.String(), "question") {
+		t.Errorf("pages lost text")
+	}
+}
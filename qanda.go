@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
@@ -12,75 +14,101 @@ import (
 	"github.com/diamondburned/arikawa/v3/gateway"
 	"github.com/diamondburned/arikawa/v3/utils/json/option"
 	"github.com/dlclark/regexp2"
-	_ "modernc.org/sqlite"
 )
 
-var responseButtonRegex = regexp2.MustCompile(`opt_(\d+)_(\d)`, regexp2.None)
+var responseButtonRegex = regexp2.MustCompile(`opt_(\d+)_(\d+)`, regexp2.None)
+
+// draftTTL is how long an unconfirmed /ask preview stays usable.
+const draftTTL = time.Hour * 24
 
 type QuestionDraft struct {
 	*Question
-	DraftID    int64
-	lastEdited time.Time
+	DraftID   int64     `db:"id"`
+	ChannelID int64     `db:"channel_id"`
+	ExpiresAt time.Time `db:"expires_at"`
+	// CloseIn closes the question that long after it is posted, rather than at CloseAt.
+	CloseIn time.Duration `db:"close_in"`
 }
 
 type Question struct {
-	QID        int64     `db:"id"`
-	CreatorID  int64     `db:"creator_id"`
-	GuildID    int64     `db:"guild_id"`
-	Question   string    `db:"question"`
-	OptionsStr string    `db:"options"`
-	Answer     int64     `db:"answer_id"`
-	CreatedAt  time.Time `db:"created_at"`
-	IsClosed   bool      `db:"is_closed"`
-	IsAnon   bool      `db:"is_anon"`
-	Options    []string
+	QID       int64     `db:"id"`
+	CreatorID int64     `db:"creator_id"`
+	GuildID   int64     `db:"guild_id"`
+	Question  string    `db:"question"`
+	CreatedAt time.Time `db:"created_at"`
+	IsClosed  bool      `db:"is_closed"`
+	IsAnon    bool      `db:"is_anon"`
+	IsMulti   bool      `db:"is_multi"`
+	// IsSurvey questions have no correct answer and are never scored.
+	IsSurvey bool   `db:"is_survey"`
+	Kind     string `db:"kind"`
+	// CloseAt is when the question closes by itself, if ever.
+	CloseAt *time.Time `db:"close_at"`
+	// IsLive questions show the running counts on their posts.
+	IsLive bool `db:"is_live"`
+	// SourceID is the question this one was cloned from, if any.
+	SourceID int64 `db:"source_question_id"`
+	// Tags are lowercase labels used to find the question again.
+	Tags    []string
+	Options []QuestionOption
+	// Accepted holds the correct answers of free-text questions.
+	Accepted []AcceptedAnswer
 }
 
-func (b *Bot) queryQuestion(qId int64) (*Question, error) {
-	q := Question{}
-	err := b.db.QueryRow(
-		"SELECT id, creator_id, guild_id, question, options, answer_id, created_at, is_closed, is_anon FROM questions WHERE id = ?",
-		qId,
-	).Scan(&q.QID, &q.CreatorID, &q.GuildID, &q.Question, &q.OptionsStr, &q.Answer, &q.CreatedAt, &q.IsClosed, &q.IsAnon)
-	if err != nil {
-		return nil, fmt.Errorf("Question not found: %w", err)
+// isCorrect reports whether choices answer the question correctly. A single choice
+// is correct when it is any of the correct options; a multi-select answer has to
+// match the set of correct options exactly.
+func (q *Question) isCorrect(choices []int) bool {
+	if q.IsSurvey || len(choices) == 0 {
+		return false
 	}
-
-	if q.OptionsStr != "" {
-		q.Options = strings.Split(q.OptionsStr, "|")
+	if !q.IsMulti {
+		return len(choices) == 1 && choices[0] >= 0 && choices[0] < len(q.Options) && q.Options[choices[0]].IsCorrect
 	}
 
-	return &q, nil
+	selected := make(map[int]bool)
+	for _, c := range choices {
+		if c < 0 || c >= len(q.Options) {
+			return false
+		}
+		selected[c] = true
+	}
+	for i, opt := range q.Options {
+		if opt.IsCorrect != selected[i] {
+			return false
+		}
+	}
+	return true
 }
 
-func (b *Bot) insertQuestion(q *Question) (*Question, error) {
-	q.OptionsStr = strings.Join(q.Options, "|")
-	result, err := b.db.Exec(
-		"INSERT INTO questions (creator_id, guild_id, question, options, answer_id, is_anon) VALUES (?, ?, ?, ?, ?, ?)",
-		q.CreatorID,
-		q.GuildID,
-		q.Question,
-		q.OptionsStr,
-		q.Answer,
-		q.IsAnon,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to store question: %w", err)
+// optionLabels joins the labels of the given options.
+func (q *Question) optionLabels(choices []int) string {
+	labels := make([]string, 0, len(choices))
+	for _, c := range choices {
+		if c >= 0 && c < len(q.Options) {
+			labels = append(labels, q.Options[c].Label)
+		}
 	}
+	return strings.Join(labels, ", ")
+}
 
-	questionID, _ := result.LastInsertId()
-	err = b.db.QueryRow(
-		"SELECT id, created_at, is_closed FROM questions WHERE id = ?",
-		questionID,
-	).Scan(&q.QID, &q.CreatedAt, &q.IsClosed)
-	if err != nil {
-		return nil, fmt.Errorf("Question not found: %w", err)
+// correctChoices returns the indices of the correct options.
+func (q *Question) correctChoices() []int {
+	var choices []int
+	for i, opt := range q.Options {
+		if opt.IsCorrect {
+			choices = append(choices, i)
+		}
 	}
-
-	return q, nil
+	return choices
 }
 
-var drafts = make(map[int64]*QuestionDraft)
+type QuestionOption struct {
+	Position  int    `db:"position"`
+	Label     string `db:"label"`
+	Emoji     string `db:"emoji"`
+	IsCorrect bool   `db:"is_correct"`
+}
 
 func (b *Bot) handleInteraction(e *gateway.InteractionCreateEvent) {
 	defer func() {
@@ -105,20 +133,45 @@ func (b *Bot) handleInteraction(e *gateway.InteractionCreateEvent) {
 			err = b.handleAnalyzeCommand(e)
 		case "list":
 			err = b.handleListCommand(e)
+		case "schedule":
+			err = b.handleScheduleCommand(e)
+		case "where":
+			err = b.handleWhereCommand(e)
+		case "edit":
+			err = b.handleEditCommand(e)
+		case "clone":
+			err = b.handleCloneCommand(e)
+		case "quiz":
+			err = b.handleQuizCommand(e)
 		case "Make questions":
 			err = b.handleParseCommand(e)
 		}
 	case *discord.ButtonInteraction:
 		err = b.handleButtonClick(e)
+	case *discord.ModalInteraction:
+		err = b.handleModalSubmit(e)
+	case *discord.StringSelectInteraction:
+		err = b.handleSelect(e)
 	}
 
 	if err != nil {
 		log.Printf("%v", err)
 	}
+}
 
-	for _, draft := range drafts {
-		if time.Since(draft.lastEdited) > time.Hour*24 {
-			delete(drafts, draft.DraftID)
+// sweepDrafts drops expired drafts every interval until ctx is done.
+func (b *Bot) sweepDrafts(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.store.DeleteExpiredDrafts(time.Now()); err != nil {
+				log.Printf("Failed to clean drafts: %v", err)
+			}
 		}
 	}
 }
@@ -130,50 +183,95 @@ func (b *Bot) handleInteraction(e *gateway.InteractionCreateEvent) {
 func (b *Bot) preparePost(q *Question) (api.SendMessageData, error) {
 
 	content := q.Question + fmt.Sprintf("-# \\#%d", q.QID)
+	if q.CloseAt != nil && !q.IsClosed {
+		content += fmt.Sprintf(" · closes <t:%d:R>", q.CloseAt.Unix())
+	}
 	
+	if q.Kind == KindText {
+		content = "[✏️ Type your answer]\n" + content
+	}
+	if q.Kind == KindNumber {
+		content = "[🔢 Answer with a number]\n" + content
+	}
+	if q.Kind == KindRank {
+		content = "[🏆 Click the options in order]\n" + content
+	}
+	if q.IsMulti {
+		content = "[☑️ Select all that apply]\n" + content
+	}
+	if q.IsSurvey {
+		content = "[📊 Survey]\n" + content
+	}
 	if q.IsAnon {
 		content = "[㊙️ Anonymous]\n" + content
 	}
 
-	components := make([]discord.Component, len(q.Options))
-	for i, opt := range q.Options {
-		components[i] = &discord.ButtonComponent{
-			CustomID: discord.ComponentID(fmt.Sprintf("opt_%d_%d", q.QID, i)),
-			Label:    opt,
-			Style:    discord.PrimaryButtonStyle(),
-		}
+	// Free-text and numeric questions are answered through a modal
+	if q.isTyped() {
+		return api.SendMessageData{
+			Content: content,
+			Components: discord.Components(
+				&discord.ButtonComponent{
+					CustomID: discord.ComponentID(fmt.Sprintf("txt_%d", q.QID)),
+					Label:    "Answer",
+					Emoji:    &discord.ComponentEmoji{Name: "✏️"},
+					Style:    discord.PrimaryButtonStyle(),
+					Disabled: q.IsClosed,
+				},
+			),
+		}, nil
+	}
+
+	components, err := q.optionComponents()
+	if err != nil {
+		return api.SendMessageData{}, err
 	}
 
 	return api.SendMessageData{
-		Content: content,
-		Components: discord.Components(
-			components...,
-		),
+		Content:    content,
+		Components: components,
 	}, nil
 }
 
-func (b *Bot) postQuestion(qId int64, channelId int64) error {
-	q, err := b.queryQuestion(qId)
+// postQuestion posts the question in the channel of the guild and remembers the message.
+func (b *Bot) postQuestion(qId int64, guildId int64, channelId int64, postedBy int64) error {
+	q, err := b.store.Question(qId)
 	if err != nil {
 		return fmt.Errorf("Question not found: %w", err)
 	}
 
-	msgData, err := b.preparePost(q)
+	msgData, err := b.renderPost(q)
 	if err != nil {
 		return err
 	}
 	// Send poll message
-	_, err = b.s.SendMessageComplex(discord.ChannelID(channelId), msgData)
+	m, err := b.s.SendMessageComplex(discord.ChannelID(channelId), msgData)
 	if err != nil {
 		return fmt.Errorf("Failed to post question:: %w", err)
 	}
 
+	// Remember the message so it can be updated when the question closes
+	err = b.store.RecordPost(&QuestionPost{
+		QuestionID: q.QID,
+		GuildID:    guildId,
+		ChannelID:  channelId,
+		MessageID:  int64(m.ID),
+		PostedBy:   postedBy,
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to record post: %w", err)
+	}
+
 	return nil
 }
 
 func (b *Bot) handleButtonClick(e *gateway.InteractionCreateEvent) error {
 	data := e.Data.(*discord.ButtonInteraction)
 
+	if strings.HasPrefix(string(data.CustomID), "page_") {
+		return b.turnPage(e, string(data.CustomID))
+	}
+
 	if strings.HasPrefix(string(data.CustomID), "ask_") {
 		askIdStr, valid := strings.CutPrefix(string(data.CustomID), "ask_")
 		if !valid {
@@ -185,19 +283,35 @@ func (b *Bot) handleButtonClick(e *gateway.InteractionCreateEvent) error {
 			return err
 		}
 
-		d, ok := drafts[askId]
-		if !ok {
+		d, err := b.store.Draft(askId)
+		if err != nil {
 			b.respondError(e, "Draft not found")
 			return err
 		}
+		if d.CreatorID != int64(e.Member.User.ID) {
+			b.respondError(e, "Only the author of the question can confirm it")
+			return nil
+		}
+		if d.CloseIn > 0 {
+			closeAt := time.Now().Add(d.CloseIn).UTC()
+			d.Question.CloseAt = &closeAt
+		} else if d.Question.CloseAt != nil && !d.Question.CloseAt.After(time.Now()) {
+			b.respondError(e, "The close time has already passed")
+			return nil
+		}
 
-		q, err := b.insertQuestion(d.Question)
+		// Confirming takes the draft, so a second click finds nothing to post
+		q, err := b.store.ConfirmDraft(d)
+		if errors.Is(err, ErrNotFound) {
+			b.respondError(e, "Draft not found")
+			return nil
+		}
 		if err != nil {
 			b.respondError(e, "Failed to insert question")
 			return err
 		}
 
-		if err := b.postQuestion(q.QID, int64(e.ChannelID)); err != nil {
+		if err := b.postQuestion(q.QID, int64(e.GuildID), int64(e.ChannelID), int64(e.Member.User.ID)); err != nil {
 			b.respondError(e, "Failed to post question")
 			return err
 		}
@@ -213,9 +327,54 @@ func (b *Bot) handleButtonClick(e *gateway.InteractionCreateEvent) error {
 			return err
 		}
 
-		delete(drafts, askId)
+		// The preview is public, so only its author may take it down
+		d, err := b.store.Draft(askId)
+		if errors.Is(err, ErrNotFound) {
+			b.respondError(e, "Draft not found")
+			return nil
+		}
+		if err != nil {
+			b.respondError(e, "Failed to get draft")
+			return err
+		}
+		if d.CreatorID != int64(e.Member.User.ID) {
+			b.respondError(e, "Only the author of the question can cancel it")
+			return nil
+		}
+
+		if err := b.store.DeleteDraft(askId); err != nil {
+			return err
+		}
 		b.s.DeleteMessage(e.ChannelID, e.Message.ID, "")
 		b.respond(e, fmt.Sprintf("Cancelled!"), discord.EphemeralMessage)
+	} else if strings.HasPrefix(string(data.CustomID), "rank_reset_") {
+		qIdStr, _ := strings.CutPrefix(string(data.CustomID), "rank_reset_")
+		qId, err := strconv.ParseInt(qIdStr, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		_, err = b.store.RecordResponse(qId, int64(e.Member.User.ID), noChoice)
+		if errors.Is(err, ErrQuestionClosed) {
+			b.respondError(e, "Poll not found or closed")
+			return err
+		}
+		if err != nil {
+			b.respondError(e, "Failed to record response")
+			return err
+		}
+		if q, err := b.store.Question(qId); err == nil && q.IsLive {
+			b.live.touch(qId)
+		}
+		b.respond(e, "🆗 Order cleared", discord.EphemeralMessage)
+	} else if strings.HasPrefix(string(data.CustomID), "txt_") {
+		qIdStr, _ := strings.CutPrefix(string(data.CustomID), "txt_")
+		qId, err := strconv.ParseInt(qIdStr, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		return b.openAnswerModal(e, qId)
 	} else if strings.HasPrefix(string(data.CustomID), "opt_") {
 		match, err := responseButtonRegex.FindStringMatch(string(data.CustomID))
 		if err != nil {
@@ -239,26 +398,44 @@ func (b *Bot) handleButtonClick(e *gateway.InteractionCreateEvent) error {
 			return err
 		}
 
-		// Extract question ID from message
-		var questionID int64
-		err = b.db.QueryRow("SELECT id FROM questions WHERE id = ? AND is_closed = FALSE", qId).Scan(&questionID)
-		if err != nil {
-			b.respondError(e, "Poll not found or closed")
-			return err
-		}
+		return b.recordChoice(e, qId, int(rId))
+	}
 
-		// Record response
-		_, err = b.db.Exec(
-			"INSERT OR REPLACE INTO responses (question_id, user_id, choice) VALUES (?, ?, ?)",
-			questionID,
-			e.Member.User.ID,
-			rId,
-		)
-		if err != nil {
-			b.respondError(e, "Failed to record response")
-			return err
-		}
+	return nil
+}
+
+// recordChoice records a click on an option button or a pick from an option menu.
+func (b *Bot) recordChoice(e *gateway.InteractionCreateEvent, qId int64, choice int) error {
+	r, err := b.store.RecordResponse(qId, int64(e.Member.User.ID), choice)
+	if errors.Is(err, ErrQuestionClosed) {
+		b.respondError(e, "Poll not found or closed")
+		return err
+	}
+	if err != nil {
+		b.respondError(e, "Failed to record response")
+		return err
+	}
+
+	q, err := b.store.Question(qId)
+	if err != nil {
+		b.respond(e, fmt.Sprintf("🆗"), discord.EphemeralMessage)
+		return nil
+	}
+	if q.IsLive {
+		b.live.touch(qId)
+	}
+	switch {
+	case q.Kind == KindRank:
+		b.respond(e, fmt.Sprintf("🆗 Your order (%d/%d): %s", len(r.Choices), len(q.Options), q.orderLabels(r.Choices)), discord.EphemeralMessage)
+	case !q.IsMulti && q.usesSelectMenu(maxRows):
+		// The menu does not show the pick once it is made
+		b.respond(e, "🆗 Selected: "+q.optionLabels(r.Choices), discord.EphemeralMessage)
+	case !q.IsMulti:
 		b.respond(e, fmt.Sprintf("🆗"), discord.EphemeralMessage)
+	case len(r.Choices) == 0:
+		b.respond(e, "🆗 Nothing selected", discord.EphemeralMessage)
+	default:
+		b.respond(e, "🆗 Selected: "+q.optionLabels(r.Choices), discord.EphemeralMessage)
 	}
 
 	return nil
@@ -290,10 +467,30 @@ func (b *Bot) respondError(e *gateway.InteractionCreateEvent, message string) {
 	}
 }
 
+// deferResponse acknowledges the interaction for commands that take longer than
+// Discord waits, to be filled in with editPages or editResponseError.
+func (b *Bot) deferResponse(e *gateway.InteractionCreateEvent, flags discord.MessageFlags) error {
+	return b.s.RespondInteraction(e.ID, e.Token, api.InteractionResponse{
+		Type: api.DeferredMessageInteractionWithSource,
+		Data: &api.InteractionResponseData{
+			Flags: flags,
+		},
+	})
+}
+
+func (b *Bot) editResponseError(e *gateway.InteractionCreateEvent, message string) {
+	_, err := b.s.EditInteractionResponse(e.AppID, e.Token, api.EditInteractionResponseData{
+		Content: option.NewNullableString("❌" + message),
+	})
+	if err != nil {
+		log.Printf("Failed to edit interaction response: %v", err)
+	}
+}
+
 func parseIds(ids string) []int64 {
 	var result []int64
 	for _, id := range strings.Split(ids, ",") {
-		i, err := strconv.ParseInt(id, 10, 64)
+		i, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64)
 		if err != nil {
 			continue
 		}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+)
+
+// The options of a ranking question are stored in their correct order. Users click
+// them in the order they choose, and their answer is the resulting permutation.
+
+// displayOrder is the order the option buttons are posted in. Scored rankings are
+// shuffled, the same way every time, so the buttons do not give the answer away.
+func (q *Question) displayOrder() []int {
+	if q.Kind == KindRank && !q.IsSurvey {
+		return rand.New(rand.NewPCG(uint64(q.QID), 0)).Perm(len(q.Options))
+	}
+	return q.correctOrder()
+}
+
+// correctOrder lists the options in the order they were written.
+func (q *Question) correctOrder() []int {
+	order := make([]int, len(q.Options))
+	for i := range order {
+		order[i] = i
+	}
+	return order
+}
+
+// rankDistance counts the pairs of options the order puts the other way round from
+// the correct order (Kendall tau distance). Options left out are placed last.
+func (q *Question) rankDistance(order []int) int {
+	full := slices.Clone(order)
+	for i := range q.Options {
+		if !slices.Contains(full, i) {
+			full = append(full, i)
+		}
+	}
+
+	distance := 0
+	for i := range full {
+		for j := i + 1; j < len(full); j++ {
+			if full[i] > full[j] {
+				distance++
+			}
+		}
+	}
+	return distance
+}
+
+// maxRankDistance is the distance of the reversed order.
+func (q *Question) maxRankDistance() int {
+	n := len(q.Options)
+	return n * (n - 1) / 2
+}
+
+// isCorrectOrder reports whether the order ranks every option correctly.
+func (q *Question) isCorrectOrder(order []int) bool {
+	return !q.IsSurvey && len(order) == len(q.Options) && q.rankDistance(order) == 0
+}
+
+// orderLabels numbers the options of an order.
+func (q *Question) orderLabels(order []int) string {
+	labels := make([]string, 0, len(order))
+	for i, c := range order {
+		if c >= 0 && c < len(q.Options) {
+			labels = append(labels, fmt.Sprintf("%d. %s", i+1, q.Options[c].Label))
+		}
+	}
+	return strings.Join(labels, " → ")
+}
+
+// bordaRanking orders the options by Borda count: an option ranked at position p
+// of n gets n-1-p points, and options a user left out get none.
+func (q *Question) bordaRanking(responses []Response, firstAttempt bool) ([]int, []int) {
+	n := len(q.Options)
+	points := make([]int, n)
+	for _, r := range responses {
+		order := r.Choices
+		if firstAttempt {
+			order = r.FirstChoices
+		}
+		for p, c := range order {
+			if c >= 0 && c < n {
+				points[c] += n - 1 - p
+			}
+		}
+	}
+
+	ranking := make([]int, n)
+	for i := range ranking {
+		ranking[i] = i
+	}
+	slices.SortStableFunc(ranking, func(a, b int) int {
+		return points[b] - points[a]
+	})
+	return ranking, points
+}
+
+// writeBordaRanking shows the aggregate order of the options.
+func writeBordaRanking(result *strings.Builder, q *Question, responses []Response, firstAttempt bool) {
+	ranking, points := q.bordaRanking(responses, firstAttempt)
+	result.WriteString("🏆 **Ranking** (Borda count)\n")
+	for i, c := range ranking {
+		result.WriteString(fmt.Sprintf("%d. **%s**: %d pts\n", i+1, q.Options[c].Label, points[c]))
+	}
+}
+
+// writeRankResults shows the aggregate ranking and every user's order, and returns
+// the number of answers.
+func writeRankResults(result *strings.Builder, q *Question, responses []Response) int {
+	if len(responses) == 0 {
+		return 0
+	}
+
+	writeBordaRanking(result, q, responses, false)
+	result.WriteString("\n")
+	for i, r := range responses {
+		if !q.IsSurvey {
+			if q.isCorrectOrder(r.Choices) {
+				result.WriteString("✅")
+			} else {
+				result.WriteString("❌")
+			}
+		}
+		if q.IsAnon {
+			result.WriteString(fmt.Sprintf("%d. `anon`: %s", i+1, q.orderLabels(r.Choices)))
+		} else {
+			result.WriteString(fmt.Sprintf("%d. <@%d>: %s", i+1, r.UserID, q.orderLabels(r.Choices)))
+		}
+		if !q.IsSurvey {
+			result.WriteString(fmt.Sprintf(" (distance %d/%d)", q.rankDistance(r.Choices), q.maxRankDistance()))
+		}
+		if r.Changes > 0 {
+			result.WriteString(fmt.Sprintf(" ✏️ restarted %dx", r.Changes))
+		}
+		result.WriteString("\n")
+	}
+	return len(responses)
+}
+
+// writeRankDistances shows how far the users' orders were from the correct one,
+// closest first.
+func writeRankDistances(result *strings.Builder, q *Question, responses []Response, firstAttempt bool) {
+	type distance struct {
+		userID   int64
+		distance int
+	}
+	var distances []distance
+	total := 0
+	for _, r := range responses {
+		order := r.Choices
+		if firstAttempt {
+			order = r.FirstChoices
+		}
+		d := q.rankDistance(order)
+		total += d
+		distances = append(distances, distance{r.UserID, d})
+	}
+	if len(distances) == 0 {
+		return
+	}
+
+	result.WriteString(fmt.Sprintf("📏 **Mean distance**: %.1f/%d\n", float64(total)/float64(len(distances)), q.maxRankDistance()))
+	if q.IsAnon {
+		return
+	}
+
+	slices.SortStableFunc(distances, func(a, b distance) int {
+		return a.distance - b.distance
+	})
+	for i := 0; i < len(distances) && i < 5; i++ {
+		result.WriteString(fmt.Sprintf("%d. <@%d>: %d/%d\n", i+1, distances[i].userID, distances[i].distance, q.maxRankDistance()))
+	}
+}
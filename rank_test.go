@@ -0,0 +1,63 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func rankQuestion(n int) *Question {
+	q := &Question{Kind: KindRank}
+	for range n {
+		q.Options = append(q.Options, QuestionOption{Label: "x"})
+	}
+	return q
+}
+
+func TestRankDistance(t *testing.T) {
+	q := rankQuestion(4)
+	for _, c := range []struct {
+		order   []int
+		want    int
+		correct bool
+	}{
+		{[]int{0, 1, 2, 3}, 0, true},
+		{[]int{1, 0, 2, 3}, 1, false},
+		{[]int{3, 2, 1, 0}, 6, false},
+		{[]int{0, 1}, 0, false},
+		{[]int{3}, 3, false},
+		{nil, 0, false},
+	} {
+		if got := q.rankDistance(c.order); got != c.want {
+			t.Errorf("rankDistance(%v) = %d, want %d", c.order, got, c.want)
+		}
+		if got := q.isCorrectOrder(c.order); got != c.correct {
+			t.Errorf("isCorrectOrder(%v) = %v", c.order, got)
+		}
+	}
+	if got := q.maxRankDistance(); got != 6 {
+		t.Errorf("maxRankDistance = %d", got)
+	}
+}
+
+func TestBordaRanking(t *testing.T) {
+	q := rankQuestion(3)
+	for _, c := range []struct {
+		name         string
+		responses    []Response
+		firstAttempt bool
+		ranking      []int
+		points       []int
+	}{
+		{"none", nil, false, []int{0, 1, 2}, []int{0, 0, 0}},
+		{"agree", []Response{{Choices: []int{2, 0, 1}}, {Choices: []int{2, 0, 1}}}, false, []int{2, 0, 1}, []int{2, 0, 4}},
+		{"ties keep option order", []Response{{Choices: []int{1, 0, 2}}, {Choices: []int{0, 1, 2}}}, false, []int{0, 1, 2}, []int{3, 3, 0}},
+		{"partial", []Response{{Choices: []int{2}}, {Choices: []int{1, 2, 0}}}, false, []int{2, 1, 0}, []int{0, 2, 3}},
+		{"first attempt", []Response{{Choices: []int{0, 1, 2}, FirstChoices: []int{2, 1, 0}}}, true, []int{2, 1, 0}, []int{0, 1, 2}},
+		{"out of range", []Response{{Choices: []int{5, 1}}}, false, []int{1, 0, 2}, []int{0, 1, 0}},
+	} {
+		ranking, points := q.bordaRanking(c.responses, c.firstAttempt)
+		if !slices.Equal(ranking, c.ranking) || !slices.Equal(points, c.points) {
+			t.Errorf("%s: bordaRanking = %v, %v, want %v, %v", c.name, ranking, points, c.ranking, c.points)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// parseInterval reads a positive duration such as 30m, 2h or 1d.
+func parseInterval(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+
+	d, err := time.ParseDuration(s)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		var n float64
+		n, err = strconv.ParseFloat(days, 64)
+		d = time.Duration(n * float64(24*time.Hour))
+	}
+	if err != nil {
+		return 0, fmt.Errorf("cannot read %q as a duration", s)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%q is not a positive duration", s)
+	}
+	return d, nil
+}
+
+// parseWhen reads a point in time relative to now: a duration such as 30m, 2h or 1d,
+// a Discord timestamp like <t:1700000000:R>, a unix time, the next 09:00, or a date
+// like 2024-05-01 18:00 or RFC 3339. Times without a zone are read in now's location.
+func parseWhen(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+
+	if d, err := parseInterval(s); err == nil {
+		return now.Add(d), nil
+	}
+
+	if t, err := time.ParseInLocation("15:04", s, now.Location()); err == nil {
+		next := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next.UTC(), nil
+	}
+
+	if stamp, ok := strings.CutPrefix(s, "<t:"); ok {
+		stamp, _, _ = strings.Cut(strings.TrimSuffix(stamp, ">"), ":")
+		s = stamp
+	}
+	if unix, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(unix, 0).UTC(), nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot read %q as a duration or time", s)
+}
+
+// parsePast reads a point in time before now, the way parseWhen does, except that a
+// duration such as 7d counts back from now and 09:00 is the last one that passed.
+func parsePast(s string, now time.Time) (time.Time, error) {
+	if d, err := parseInterval(s); err == nil {
+		return now.Add(-d).UTC(), nil
+	}
+	t, err := parseWhen(s, now)
+	if err != nil {
+		return t, err
+	}
+	if _, err := time.Parse("15:04", strings.TrimSpace(s)); err == nil && t.After(now) {
+		t = t.In(now.Location()).AddDate(0, 0, -1).UTC()
+	}
+	return t, nil
+}
+
+// guildNow is the current time in the guild's timezone.
+func (b *Bot) guildNow(guildID discord.GuildID) time.Time {
+	now := time.Now()
+	tz, err := b.store.GuildTimezone(int64(guildID))
+	if err != nil {
+		log.Printf("Failed to get timezone of guild %d: %v", guildID, err)
+		return now.UTC()
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return now.UTC()
+	}
+	return now.In(loc)
+}
+
+// maxPostAttempts is how many times a scheduled post is tried before it is dropped.
+const maxPostAttempts = 5
+
+// postScheduled posts the scheduled questions that are due, every interval until
+// ctx is done. Each post is taken off the queue before it is sent, so it is never
+// posted twice. A post that fails is queued again under its ID for the next tick, up to
+// maxPostAttempts times, unless its question is gone.
+func (b *Bot) postScheduled(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			posts, err := b.store.DueScheduledPosts(time.Now())
+			if err != nil {
+				log.Printf("Failed to get scheduled posts: %v", err)
+				continue
+			}
+			for i, p := range posts {
+				if i > 0 {
+					<-time.NewTimer(time.Second).C
+				}
+				b.postScheduledPost(p)
+			}
+		}
+	}
+}
+
+// postScheduledPost takes p off the queue and posts it.
+func (b *Bot) postScheduledPost(p ScheduledPost) {
+	claimed, err := b.store.DeleteScheduledPost(p.ID, p.GuildID)
+	if err != nil {
+		log.Printf("Failed to claim scheduled post %d: %v", p.ID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	if err := b.postQuestion(p.QuestionID, p.GuildID, p.ChannelID, p.CreatorID); err != nil {
+		p.Attempts++
+		if errors.Is(err, ErrNotFound) || p.Attempts >= maxPostAttempts {
+			log.Printf("Dropped scheduled post %d of question %d after %d attempts: %v", p.ID, p.QuestionID, p.Attempts, err)
+			return
+		}
+		log.Printf("Failed to post scheduled question %d, attempt %d: %v", p.QuestionID, p.Attempts, err)
+		if err := b.store.RequeueScheduledPost(&p); err != nil {
+			log.Printf("Failed to queue scheduled question %d again: %v", p.QuestionID, err)
+		}
+		return
+	}
+
+	if p.CloseAfter > 0 {
+		closeAt := time.Now().Add(p.CloseAfter)
+		if err := b.store.ScheduleClose(p.QuestionID, &closeAt); err != nil {
+			log.Printf("Failed to schedule close of question %d: %v", p.QuestionID, err)
+			return
+		}
+		// Show the close time on the post that just went out
+		b.refreshPosts(p.QuestionID)
+	}
+}
+
+// closeScheduled closes the questions whose close time has come, every interval until
+// ctx is done. Close times are stored with the questions, so questions that came due
+// while the bot was down close on the first tick after a restart.
+func (b *Bot) closeScheduled(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			questions, err := b.store.DueQuestions(time.Now())
+			if err != nil {
+				log.Printf("Failed to get due questions: %v", err)
+				continue
+			}
+			for _, q := range questions {
+				closed, err := b.store.CloseQuestion(q.QID, q.GuildID)
+				if err != nil {
+					log.Printf("Failed to close question %d: %v", q.QID, err)
+					continue
+				}
+				if closed {
+					b.closePosts(q.QID)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWhen(t *testing.T) {
+	tz := time.FixedZone("UTC+9", 9*60*60)
+	now := time.Date(2024, 5, 1, 12, 30, 0, 0, tz)
+	for _, c := range []struct {
+		in   string
+		want time.Time
+		ok   bool
+	}{
+		{"30m", now.Add(30 * time.Minute), true},
+		{" 2h ", now.Add(2 * time.Hour), true},
+		{"1d", now.Add(24 * time.Hour), true},
+		{"18:00", time.Date(2024, 5, 1, 18, 0, 0, 0, tz), true},
+		{"09:00", time.Date(2024, 5, 2, 9, 0, 0, 0, tz), true},
+		{"12:30", time.Date(2024, 5, 2, 12, 30, 0, 0, tz), true},
+		{"<t:1700000000:R>", time.Unix(1700000000, 0), true},
+		{"1700000000", time.Unix(1700000000, 0), true},
+		{"2024-05-03 08:15", time.Date(2024, 5, 3, 8, 15, 0, 0, tz), true},
+		{"2024-05-03", time.Date(2024, 5, 3, 0, 0, 0, 0, tz), true},
+		{"2024-05-03T08:15:00Z", time.Date(2024, 5, 3, 8, 15, 0, 0, time.UTC), true},
+		{"-1h", time.Time{}, false},
+		{"tomorrow", time.Time{}, false},
+	} {
+		got, err := parseWhen(c.in, now)
+		if (err == nil) != c.ok || !got.Equal(c.want) {
+			t.Errorf("parseWhen(%q) = %v, %v, want %v", c.in, got, err, c.want)
+		}
+	}
+}
+
+func TestParsePast(t *testing.T) {
+	tz := time.FixedZone("UTC+9", 9*60*60)
+	now := time.Date(2024, 5, 1, 12, 30, 0, 0, tz)
+	for _, c := range []struct {
+		in   string
+		want time.Time
+		ok   bool
+	}{
+		{"7d", now.AddDate(0, 0, -7), true},
+		{"2h", now.Add(-2 * time.Hour), true},
+		{"09:00", time.Date(2024, 5, 1, 9, 0, 0, 0, tz), true},
+		{"18:00", time.Date(2024, 4, 30, 18, 0, 0, 0, tz), true},
+		{"2024-04-01", time.Date(2024, 4, 1, 0, 0, 0, 0, tz), true},
+		{"<t:1700000000:d>", time.Unix(1700000000, 0), true},
+		{"last week", time.Time{}, false},
+	} {
+		got, err := parsePast(c.in, now)
+		if (err == nil) != c.ok || !got.Equal(c.want) {
+			t.Errorf("parsePast(%q) = %v, %v, want %v", c.in, got, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+var (
+	ErrNotFound       = errors.New("not found")
+	ErrQuestionClosed = errors.New("question not found or closed")
+	ErrHasResponses   = errors.New("question already has responses")
+	ErrQuizExists     = errors.New("quiz already exists")
+)
+
+// QuestionStore is the persistence layer used by the command handlers.
+// Implementations must be safe for concurrent use, as interactions are handled concurrently.
+type QuestionStore interface {
+	// CreateQuestion stores q and its options, filling in QID, CreatedAt and IsClosed.
+	CreateQuestion(q *Question) (*Question, error)
+	// Question returns the question with its options, or ErrNotFound.
+	Question(id int64) (*Question, error)
+	// RecentQuestions returns up to limit questions of the guild matching the filter, oldest
+	// first or newest first as the filter asks. A limit of 0 returns every match.
+	RecentQuestions(guildID int64, filter QuestionFilter, limit int) ([]*Question, error)
+	// CloseQuestion closes an open question of the guild and reports whether anything changed.
+	CloseQuestion(id int64, guildID int64) (bool, error)
+	// ScheduleClose sets when the question closes by itself, or clears it when at is nil.
+	ScheduleClose(id int64, at *time.Time) error
+	// UpdateQuestion saves the text, anon flag, options and accepted answers of q and logs the
+	// edit, filling in its ID and EditedAt. Changing the options of a question that has responses
+	// returns ErrHasResponses, as the responses refer to the options by position.
+	UpdateQuestion(q *Question, edit *QuestionEdit) error
+	// DueQuestions returns the open questions whose close time is not after now, without their answers.
+	DueQuestions(now time.Time) ([]*Question, error)
+
+	// CreateScheduledPost stores p under a fresh ID.
+	CreateScheduledPost(p *ScheduledPost) error
+	// RequeueScheduledPost stores a post taken off the queue again under its own ID,
+	// so it can still be cancelled while it is retried.
+	RequeueScheduledPost(p *ScheduledPost) error
+	// ScheduledPosts returns the pending posts of the guild, soonest first.
+	ScheduledPosts(guildID int64) ([]ScheduledPost, error)
+	// DueScheduledPosts returns the pending posts of every guild that are due at now, soonest first.
+	DueScheduledPosts(now time.Time) ([]ScheduledPost, error)
+	// DeleteScheduledPost drops a pending post of the guild and reports whether it existed.
+	DeleteScheduledPost(id int64, guildID int64) (bool, error)
+
+	// CreateQuiz stores an empty quiz under a fresh ID, or returns ErrQuizExists if the
+	// guild already has a quiz of that name.
+	CreateQuiz(quiz *Quiz) error
+	// Quiz returns the quiz of the guild with its questions, or ErrNotFound.
+	Quiz(guildID int64, name string) (*Quiz, error)
+	// Quizzes returns the quizzes of the guild with their questions, by name.
+	Quizzes(guildID int64) ([]*Quiz, error)
+	// AddQuizQuestions appends the questions to the quiz, skipping those already in it,
+	// and returns how many were added. Nothing is added, and ErrNotFound is returned,
+	// if the quiz or any of the questions does not exist.
+	AddQuizQuestions(quizID int64, questionIDs []int64) (int, error)
+
+	// GuildTimezone returns the IANA timezone name of the guild, UTC unless set.
+	GuildTimezone(guildID int64) (string, error)
+	SetGuildTimezone(guildID int64, timezone string) error
+
+	// RecordPost remembers a message the question was posted in.
+	RecordPost(p *QuestionPost) error
+	// Posts returns the messages the question was posted in, oldest first.
+	Posts(questionID int64) ([]QuestionPost, error)
+
+	// RecordResponse appends the user's click to the response log and returns their answer after it,
+	// or returns ErrQuestionClosed. On multi-select questions the click toggles the option; on ranking
+	// questions it places the option next, and noChoice starts the order over.
+	RecordResponse(questionID int64, userID int64, choice int) (*Response, error)
+	// RecordValue appends the user's typed answer to the response log, or returns ErrQuestionClosed.
+	RecordValue(questionID int64, userID int64, value string) (*Response, error)
+	// ResponseEvents returns the response log of the question, oldest first.
+	ResponseEvents(questionID int64) ([]ResponseEvent, error)
+	// Responses returns the current answer of every user, derived from the response log
+	// and ordered by when it was last changed.
+	Responses(questionID int64) ([]Response, error)
+
+	// CreateDraft stores d under a fresh DraftID.
+	CreateDraft(d *QuestionDraft) error
+	// Draft returns the draft, or ErrNotFound if it does not exist or has expired.
+	Draft(id int64) (*QuestionDraft, error)
+	// ConfirmDraft deletes the draft and stores its question in one step, so a draft
+	// is only ever confirmed once. It returns ErrNotFound if the draft is gone or has expired.
+	ConfirmDraft(d *QuestionDraft) (*Question, error)
+	DeleteDraft(id int64) error
+	// DeleteExpiredDrafts drops every draft that expired before now.
+	DeleteExpiredDrafts(now time.Time) error
+
+	Close() error
+}
+
+// QuestionFilter narrows down the questions of a guild. Empty fields match every question.
+type QuestionFilter struct {
+	// Tags matches the questions with any of the tags.
+	Tags []string
+	// Since and Until bound when the question was created, Until excluded.
+	Since time.Time
+	Until time.Time
+	// Closed matches the closed questions when true and the open ones when false.
+	Closed    *bool
+	CreatorID int64
+	// Anon matches the anonymous questions when true and the others when false.
+	Anon        *bool
+	NewestFirst bool
+}
+
+// isEmpty reports whether the filter matches every question.
+func (f *QuestionFilter) isEmpty() bool {
+	return len(f.Tags) == 0 && f.Since.IsZero() && f.Until.IsZero() && f.Closed == nil && f.CreatorID == 0 && f.Anon == nil
+}
+
+func (f *QuestionFilter) matches(q *Question) bool {
+	switch {
+	case len(f.Tags) > 0 && !slices.ContainsFunc(f.Tags, func(tag string) bool { return slices.Contains(q.Tags, tag) }):
+		return false
+	case !f.Since.IsZero() && q.CreatedAt.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !q.CreatedAt.Before(f.Until):
+		return false
+	case f.Closed != nil && q.IsClosed != *f.Closed:
+		return false
+	case f.CreatorID != 0 && q.CreatorID != f.CreatorID:
+		return false
+	case f.Anon != nil && q.IsAnon != *f.Anon:
+		return false
+	}
+	return true
+}
+
+// QuestionPost is a message a question was posted in.
+type QuestionPost struct {
+	QuestionID int64     `db:"question_id"`
+	GuildID    int64     `db:"guild_id"`
+	ChannelID  int64     `db:"channel_id"`
+	MessageID  int64     `db:"message_id"`
+	PostedBy   int64     `db:"posted_by"`
+	PostedAt   time.Time `db:"posted_at"`
+}
+
+// jumpURL links to the message of the post.
+func (p *QuestionPost) jumpURL() string {
+	return fmt.Sprintf("https://discord.com/channels/%d/%d/%d", p.GuildID, p.ChannelID, p.MessageID)
+}
+
+// Quiz is a named set of questions of a guild, in the order they are posted.
+type Quiz struct {
+	ID          int64     `db:"id"`
+	GuildID     int64     `db:"guild_id"`
+	Name        string    `db:"name"`
+	CreatorID   int64     `db:"creator_id"`
+	CreatedAt   time.Time `db:"created_at"`
+	QuestionIDs []int64
+}
+
+// QuestionEdit is an audit entry of a change made to a question.
+type QuestionEdit struct {
+	ID         int64     `db:"id"`
+	QuestionID int64     `db:"question_id"`
+	EditorID   int64     `db:"editor_id"`
+	Summary    string    `db:"summary"`
+	EditedAt   time.Time `db:"edited_at"`
+}
+
+// sameOptions reports whether two option lists offer the same choices in the same order.
+// Which of them are correct does not matter.
+func sameOptions(a []QuestionOption, b []QuestionOption) bool {
+	return slices.EqualFunc(a, b, func(x, y QuestionOption) bool {
+		return x.Label == y.Label && x.Emoji == y.Emoji
+	})
+}
+
+// ScheduledPost is a question waiting to be posted. CloseAfter, when set, schedules
+// the question to close that long after it is posted. Attempts counts the failed tries.
+type ScheduledPost struct {
+	ID         int64         `db:"id"`
+	GuildID    int64         `db:"guild_id"`
+	ChannelID  int64         `db:"channel_id"`
+	QuestionID int64         `db:"question_id"`
+	CreatorID  int64         `db:"creator_id"`
+	PostAt     time.Time     `db:"post_at"`
+	CloseAfter time.Duration `db:"close_after"`
+	Attempts   int           `db:"attempts"`
+}
+
+// ResponseEvent is a single button click or typed answer. Selected is false when the
+// click took an option back out of a multi-select answer. Typed answers have no Choice.
+type ResponseEvent struct {
+	ID          int64     `db:"id"`
+	QuestionID  int64     `db:"question_id"`
+	UserID      int64     `db:"user_id"`
+	Choice      int       `db:"choice"`
+	Selected    bool      `db:"selected"`
+	Value       string    `db:"value"`
+	RespondedAt time.Time `db:"responded_at"`
+}
+
+// noChoice is the choice recorded for typed answers.
+const noChoice = -1
+
+// Response is a user's answer to a question, folded from their events.
+// Choices holds a single option unless the question is multi-select.
+type Response struct {
+	QuestionID  int64
+	UserID      int64
+	Choices     []int
+	Value       string
+	RespondedAt time.Time
+
+	// FirstChoices is the answer before the user changed their mind: the first click on
+	// single-select questions, the selection before the first deselect on multi-select ones.
+	FirstChoices     []int
+	FirstValue       string
+	FirstRespondedAt time.Time
+	// Changes counts the clicks that replaced or removed a previous choice.
+	Changes int
+}
+
+func (r *Response) hasChoice(choice int) bool {
+	return slices.Contains(r.Choices, choice)
+}
+
+// foldMode selects how a user's clicks combine into their answer.
+type foldMode int
+
+const (
+	// foldSingle keeps the last click.
+	foldSingle foldMode = iota
+	// foldMulti toggles options in and out of a set.
+	foldMulti
+	// foldRank appends options to an order until it is reset.
+	foldRank
+)
+
+func questionFoldMode(kind string, multi bool) foldMode {
+	switch {
+	case kind == KindRank:
+		return foldRank
+	case multi:
+		return foldMulti
+	}
+	return foldSingle
+}
+
+// foldResponses derives the current responses from an ordered event log.
+// Users whose multi-select answer or ranking ended up empty are left out.
+func foldResponses(events []ResponseEvent, mode foldMode) []Response {
+	var responses []Response
+	index := make(map[int64]int)
+	changed := make(map[int64]bool)
+	for _, ev := range events {
+		i, ok := index[ev.UserID]
+		if !ok {
+			i = len(responses)
+			index[ev.UserID] = i
+			responses = append(responses, Response{
+				QuestionID:       ev.QuestionID,
+				UserID:           ev.UserID,
+				FirstRespondedAt: ev.RespondedAt,
+			})
+		}
+		r := &responses[i]
+		r.RespondedAt = ev.RespondedAt
+
+		switch {
+		case mode == foldRank:
+			if ev.Choice == noChoice {
+				if len(r.Choices) > 0 {
+					r.Changes++
+					changed[ev.UserID] = true
+				}
+				r.Choices = nil
+			} else if !r.hasChoice(ev.Choice) {
+				r.Choices = append(r.Choices, ev.Choice)
+				if !changed[ev.UserID] {
+					r.FirstChoices = slices.Clone(r.Choices)
+				}
+			}
+		case mode == foldSingle:
+			r.Choices = nil
+			if ev.Choice != noChoice {
+				r.Choices = []int{ev.Choice}
+			}
+			r.Value = ev.Value
+			if ok {
+				r.Changes++
+			} else {
+				r.FirstChoices = r.Choices
+				r.FirstValue = r.Value
+			}
+		case ev.Selected:
+			if !r.hasChoice(ev.Choice) {
+				r.Choices = append(r.Choices, ev.Choice)
+				slices.Sort(r.Choices)
+			}
+			if !changed[ev.UserID] {
+				r.FirstChoices = slices.Clone(r.Choices)
+			}
+		default:
+			r.Choices = slices.DeleteFunc(r.Choices, func(c int) bool { return c == ev.Choice })
+			r.Changes++
+			changed[ev.UserID] = true
+		}
+	}
+
+	if mode != foldSingle {
+		responses = slices.DeleteFunc(responses, func(r Response) bool { return len(r.Choices) == 0 })
+	}
+	sortResponses(responses)
+	return responses
+}
+
+func sortResponses(responses []Response) {
+	sort.SliceStable(responses, func(i, j int) bool {
+		return responses[i].RespondedAt.Before(responses[j].RespondedAt)
+	})
+}
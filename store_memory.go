@@ -0,0 +1,523 @@
+package main
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ QuestionStore = (*memoryStore)(nil)
+
+// memoryStore is a QuestionStore kept entirely in process memory.
+type memoryStore struct {
+	mu          sync.Mutex
+	nextID      int64
+	nextDraftID int64
+	questions   map[int64]*Question
+	events      map[int64][]ResponseEvent
+	nextEventID int64
+	drafts      map[int64]*QuestionDraft
+	posts       map[int64][]QuestionPost
+	scheduled   map[int64]ScheduledPost
+	nextSchedID int64
+	timezones   map[int64]string
+	edits       map[int64][]QuestionEdit
+	nextEditID  int64
+	quizzes     map[int64]*Quiz
+	nextQuizID  int64
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		questions: make(map[int64]*Question),
+		events:    make(map[int64][]ResponseEvent),
+		drafts:    make(map[int64]*QuestionDraft),
+		posts:     make(map[int64][]QuestionPost),
+		scheduled: make(map[int64]ScheduledPost),
+		timezones: make(map[int64]string),
+		edits:     make(map[int64][]QuestionEdit),
+		quizzes:   make(map[int64]*Quiz),
+	}
+}
+
+func (s *memoryStore) Close() error {
+	return nil
+}
+
+// copyQuestion keeps callers from mutating stored questions.
+func copyQuestion(q *Question) *Question {
+	c := *q
+	c.Options = append([]QuestionOption(nil), q.Options...)
+	c.Accepted = append([]AcceptedAnswer(nil), q.Accepted...)
+	c.Tags = append([]string(nil), q.Tags...)
+	if q.CloseAt != nil {
+		closeAt := *q.CloseAt
+		c.CloseAt = &closeAt
+	}
+	return &c
+}
+
+func (s *memoryStore) CreateQuestion(q *Question) (*Question, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.createQuestion(q), nil
+}
+
+// createQuestion stores q; the caller holds s.mu.
+func (s *memoryStore) createQuestion(q *Question) *Question {
+	s.nextID++
+	q.QID = s.nextID
+	q.CreatedAt = time.Now().UTC()
+	q.IsClosed = false
+	if q.Kind == "" {
+		q.Kind = KindChoice
+	}
+	for i := range q.Options {
+		q.Options[i].Position = i
+	}
+	for i := range q.Accepted {
+		q.Accepted[i].Position = i
+	}
+	stored := copyQuestion(q)
+	slices.Sort(stored.Tags)
+	s.questions[q.QID] = stored
+	return q
+}
+
+func (s *memoryStore) Question(id int64) (*Question, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q, ok := s.questions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyQuestion(q), nil
+}
+
+func (s *memoryStore) RecentQuestions(guildID int64, filter QuestionFilter, limit int) ([]*Question, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var questions []*Question
+	for _, q := range s.questions {
+		if q.GuildID == guildID && filter.matches(q) {
+			questions = append(questions, copyQuestion(q))
+		}
+	}
+	// Same order as the SQL store: by creation time, then by ID
+	sort.Slice(questions, func(i, j int) bool {
+		a, b := questions[i], questions[j]
+		if filter.NewestFirst {
+			a, b = b, a
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.QID < b.QID
+	})
+	if limit > 0 && len(questions) > limit {
+		questions = questions[:limit]
+	}
+	return questions, nil
+}
+
+func (s *memoryStore) CloseQuestion(id int64, guildID int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q, ok := s.questions[id]
+	if !ok || q.GuildID != guildID || q.IsClosed {
+		return false, nil
+	}
+	q.IsClosed = true
+	return true, nil
+}
+
+func (s *memoryStore) UpdateQuestion(q *Question, edit *QuestionEdit) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.questions[q.QID]
+	if !ok {
+		return ErrNotFound
+	}
+	if !sameOptions(stored.Options, q.Options) && len(s.events[q.QID]) > 0 {
+		return ErrHasResponses
+	}
+
+	updated := copyQuestion(q)
+	for i := range updated.Options {
+		updated.Options[i].Position = i
+	}
+	for i := range updated.Accepted {
+		updated.Accepted[i].Position = i
+	}
+	stored.Question = updated.Question
+	stored.IsAnon = updated.IsAnon
+	stored.Options = updated.Options
+	stored.Accepted = updated.Accepted
+
+	s.nextEditID++
+	edit.ID = s.nextEditID
+	edit.QuestionID = q.QID
+	edit.EditedAt = time.Now().UTC()
+	s.edits[q.QID] = append(s.edits[q.QID], *edit)
+	return nil
+}
+
+func (s *memoryStore) ScheduleClose(id int64, at *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q, ok := s.questions[id]
+	if !ok {
+		return nil
+	}
+	q.CloseAt = nil
+	if at != nil {
+		closeAt := at.UTC()
+		q.CloseAt = &closeAt
+	}
+	return nil
+}
+
+func (s *memoryStore) DueQuestions(now time.Time) ([]*Question, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var questions []*Question
+	for _, q := range s.questions {
+		if !q.IsClosed && q.CloseAt != nil && !q.CloseAt.After(now) {
+			c := copyQuestion(q)
+			c.Options, c.Accepted, c.Tags = nil, nil, nil
+			questions = append(questions, c)
+		}
+	}
+	sort.Slice(questions, func(i, j int) bool {
+		return questions[i].CloseAt.Before(*questions[j].CloseAt)
+	})
+	return questions, nil
+}
+
+func (s *memoryStore) CreateScheduledPost(p *ScheduledPost) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextSchedID++
+	p.ID = s.nextSchedID
+	s.scheduled[p.ID] = *p
+	return nil
+}
+
+func (s *memoryStore) RequeueScheduledPost(p *ScheduledPost) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scheduled[p.ID] = *p
+	return nil
+}
+
+// scheduledPosts returns the pending posts that keep reports true for, soonest first.
+func (s *memoryStore) scheduledPosts(keep func(p *ScheduledPost) bool) []ScheduledPost {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var posts []ScheduledPost
+	for _, p := range s.scheduled {
+		if keep(&p) {
+			posts = append(posts, p)
+		}
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		if posts[i].PostAt.Equal(posts[j].PostAt) {
+			return posts[i].ID < posts[j].ID
+		}
+		return posts[i].PostAt.Before(posts[j].PostAt)
+	})
+	return posts
+}
+
+func (s *memoryStore) ScheduledPosts(guildID int64) ([]ScheduledPost, error) {
+	return s.scheduledPosts(func(p *ScheduledPost) bool { return p.GuildID == guildID }), nil
+}
+
+func (s *memoryStore) DueScheduledPosts(now time.Time) ([]ScheduledPost, error) {
+	return s.scheduledPosts(func(p *ScheduledPost) bool { return !p.PostAt.After(now) }), nil
+}
+
+func (s *memoryStore) DeleteScheduledPost(id int64, guildID int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.scheduled[id]
+	if !ok || p.GuildID != guildID {
+		return false, nil
+	}
+	delete(s.scheduled, id)
+	return true, nil
+}
+
+func (s *memoryStore) CreateQuiz(quiz *Quiz) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, q := range s.quizzes {
+		if q.GuildID == quiz.GuildID && q.Name == quiz.Name {
+			return ErrQuizExists
+		}
+	}
+
+	s.nextQuizID++
+	quiz.ID = s.nextQuizID
+	quiz.CreatedAt = time.Now().UTC()
+	quiz.QuestionIDs = nil
+	stored := *quiz
+	s.quizzes[quiz.ID] = &stored
+	return nil
+}
+
+// copyQuiz keeps callers from mutating stored quizzes.
+func copyQuiz(quiz *Quiz) *Quiz {
+	c := *quiz
+	c.QuestionIDs = append([]int64(nil), quiz.QuestionIDs...)
+	return &c
+}
+
+func (s *memoryStore) Quiz(guildID int64, name string) (*Quiz, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, quiz := range s.quizzes {
+		if quiz.GuildID == guildID && quiz.Name == name {
+			return copyQuiz(quiz), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *memoryStore) Quizzes(guildID int64) ([]*Quiz, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var quizzes []*Quiz
+	for _, quiz := range s.quizzes {
+		if quiz.GuildID == guildID {
+			quizzes = append(quizzes, copyQuiz(quiz))
+		}
+	}
+	sort.Slice(quizzes, func(i, j int) bool {
+		return quizzes[i].Name < quizzes[j].Name
+	})
+	return quizzes, nil
+}
+
+func (s *memoryStore) AddQuizQuestions(quizID int64, questionIDs []int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	quiz, ok := s.quizzes[quizID]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	for _, id := range questionIDs {
+		if _, ok := s.questions[id]; !ok {
+			return 0, ErrNotFound
+		}
+	}
+	added := 0
+	for _, id := range questionIDs {
+		if !slices.Contains(quiz.QuestionIDs, id) {
+			quiz.QuestionIDs = append(quiz.QuestionIDs, id)
+			added++
+		}
+	}
+	return added, nil
+}
+
+func (s *memoryStore) GuildTimezone(guildID int64) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if tz, ok := s.timezones[guildID]; ok {
+		return tz, nil
+	}
+	return "UTC", nil
+}
+
+func (s *memoryStore) SetGuildTimezone(guildID int64, timezone string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.timezones[guildID] = timezone
+	return nil
+}
+
+func (s *memoryStore) RecordPost(p *QuestionPost) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p.PostedAt = time.Now().UTC()
+	s.posts[p.QuestionID] = append(s.posts[p.QuestionID], *p)
+	return nil
+}
+
+func (s *memoryStore) Posts(questionID int64) ([]QuestionPost, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]QuestionPost(nil), s.posts[questionID]...), nil
+}
+
+func (s *memoryStore) RecordResponse(questionID int64, userID int64, choice int) (*Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q, ok := s.questions[questionID]
+	if !ok || q.IsClosed {
+		return nil, ErrQuestionClosed
+	}
+
+	var userEvents []ResponseEvent
+	for _, ev := range s.events[questionID] {
+		if ev.UserID == userID {
+			userEvents = append(userEvents, ev)
+		}
+	}
+
+	// Multi-select clicks toggle the option
+	mode := questionFoldMode(q.Kind, q.IsMulti)
+	selected := true
+	if mode == foldMulti {
+		if current := foldResponses(userEvents, mode); len(current) == 1 {
+			selected = !current[0].hasChoice(choice)
+		}
+	}
+
+	s.nextEventID++
+	ev := ResponseEvent{
+		ID:          s.nextEventID,
+		QuestionID:  questionID,
+		UserID:      userID,
+		Choice:      choice,
+		Selected:    selected,
+		RespondedAt: time.Now().UTC(),
+	}
+	s.events[questionID] = append(s.events[questionID], ev)
+
+	r := Response{QuestionID: questionID, UserID: userID}
+	if current := foldResponses(append(userEvents, ev), mode); len(current) == 1 {
+		r = current[0]
+	}
+	return &r, nil
+}
+
+func (s *memoryStore) RecordValue(questionID int64, userID int64, value string) (*Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q, ok := s.questions[questionID]
+	if !ok || q.IsClosed {
+		return nil, ErrQuestionClosed
+	}
+
+	var userEvents []ResponseEvent
+	for _, ev := range s.events[questionID] {
+		if ev.UserID == userID {
+			userEvents = append(userEvents, ev)
+		}
+	}
+
+	s.nextEventID++
+	ev := ResponseEvent{
+		ID:          s.nextEventID,
+		QuestionID:  questionID,
+		UserID:      userID,
+		Choice:      noChoice,
+		Selected:    true,
+		Value:       value,
+		RespondedAt: time.Now().UTC(),
+	}
+	s.events[questionID] = append(s.events[questionID], ev)
+
+	r := foldResponses(append(userEvents, ev), foldSingle)[0]
+	return &r, nil
+}
+
+func (s *memoryStore) ResponseEvents(questionID int64) ([]ResponseEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]ResponseEvent(nil), s.events[questionID]...), nil
+}
+
+func (s *memoryStore) Responses(questionID int64) ([]Response, error) {
+	q, err := s.Question(questionID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.ResponseEvents(questionID)
+	if err != nil {
+		return nil, err
+	}
+	return foldResponses(events, questionFoldMode(q.Kind, q.IsMulti)), nil
+}
+
+func (s *memoryStore) CreateDraft(d *QuestionDraft) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextDraftID++
+	d.DraftID = s.nextDraftID
+	c := *d
+	c.Question = copyQuestion(d.Question)
+	s.drafts[d.DraftID] = &c
+	return nil
+}
+
+func (s *memoryStore) Draft(id int64) (*QuestionDraft, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.drafts[id]
+	if !ok || !d.ExpiresAt.After(time.Now()) {
+		return nil, ErrNotFound
+	}
+	c := *d
+	c.Question = copyQuestion(d.Question)
+	return &c, nil
+}
+
+func (s *memoryStore) ConfirmDraft(d *QuestionDraft) (*Question, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.drafts[d.DraftID]
+	if !ok || !stored.ExpiresAt.After(time.Now()) {
+		return nil, ErrNotFound
+	}
+	delete(s.drafts, d.DraftID)
+	return s.createQuestion(d.Question), nil
+}
+
+func (s *memoryStore) DeleteDraft(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.drafts, id)
+	return nil
+}
+
+func (s *memoryStore) DeleteExpiredDrafts(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, d := range s.drafts {
+		if !d.ExpiresAt.After(now) {
+			delete(s.drafts, id)
+		}
+	}
+	return nil
+}
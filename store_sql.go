@@ -0,0 +1,920 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// sqlStore is the QuestionStore for database/sql backends.
+type sqlStore struct {
+	db *dbConn
+}
+
+// openSQLStore connects to the database and migrates it to the latest schema.
+func openSQLStore(d *dialect, dsn string) (*sqlStore, error) {
+	db, err := sql.Open(d.driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	conn := &dbConn{DB: db, dialect: d}
+	if err := migrate(conn); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	return &sqlStore{db: conn}, nil
+}
+
+func (s *sqlStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *sqlStore) CreateQuestion(q *Question) (*Question, error) {
+	if q.Kind == "" {
+		q.Kind = KindChoice
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to store question: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertQuestion(tx, q); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to store question: %w", err)
+	}
+
+	return q, nil
+}
+
+// insertQuestion stores q with its answers and tags, filling in QID, CreatedAt and IsClosed.
+func insertQuestion(tx *dbTx, q *Question) error {
+	err := tx.QueryRow(
+		"INSERT INTO questions (creator_id, guild_id, question, is_anon, is_multi, is_survey, kind, close_at, is_live, source_question_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, created_at, is_closed",
+		q.CreatorID,
+		q.GuildID,
+		q.Question,
+		q.IsAnon,
+		q.IsMulti,
+		q.IsSurvey,
+		q.Kind,
+		nullTime(q.CloseAt),
+		q.IsLive,
+		sql.NullInt64{Int64: q.SourceID, Valid: q.SourceID != 0},
+	).Scan(&q.QID, &q.CreatedAt, &q.IsClosed)
+	if err != nil {
+		return fmt.Errorf("failed to store question: %w", err)
+	}
+
+	if err := insertAnswers(tx, q); err != nil {
+		return err
+	}
+
+	for _, tag := range q.Tags {
+		if _, err := tx.Exec("INSERT INTO question_tags (question_id, tag) VALUES (?, ?)", q.QID, tag); err != nil {
+			return fmt.Errorf("failed to store tags: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// insertAnswers stores the options and accepted answers of q.
+func insertAnswers(tx *dbTx, q *Question) error {
+	for i := range q.Options {
+		q.Options[i].Position = i
+		_, err := tx.Exec(
+			"INSERT INTO question_options (question_id, position, label, emoji, is_correct) VALUES (?, ?, ?, ?, ?)",
+			q.QID,
+			i,
+			q.Options[i].Label,
+			q.Options[i].Emoji,
+			q.Options[i].IsCorrect,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to store options: %w", err)
+		}
+	}
+
+	for i := range q.Accepted {
+		q.Accepted[i].Position = i
+		_, err := tx.Exec(
+			"INSERT INTO accepted_answers (question_id, position, value, match_rule, tolerance) VALUES (?, ?, ?, ?, ?)",
+			q.QID,
+			i,
+			q.Accepted[i].Value,
+			q.Accepted[i].Match,
+			q.Accepted[i].Tolerance,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to store accepted answers: %w", err)
+		}
+	}
+	return nil
+}
+
+// questionColumns is the column list read by scanQuestion.
+const questionColumns = "id, creator_id, guild_id, question, created_at, is_closed, is_anon, is_multi, is_survey, kind, close_at, is_live, source_question_id"
+
+func scanQuestion(row interface{ Scan(...any) error }) (*Question, error) {
+	q := &Question{}
+	var closeAt sql.NullTime
+	var sourceID sql.NullInt64
+	err := row.Scan(&q.QID, &q.CreatorID, &q.GuildID, &q.Question, &q.CreatedAt, &q.IsClosed, &q.IsAnon, &q.IsMulti, &q.IsSurvey, &q.Kind, &closeAt, &q.IsLive, &sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if closeAt.Valid {
+		q.CloseAt = &closeAt.Time
+	}
+	q.SourceID = sourceID.Int64
+	return q, nil
+}
+
+// nullTime stores a missing time as NULL.
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+func (s *sqlStore) Question(id int64) (*Question, error) {
+	q, err := scanQuestion(s.db.QueryRow("SELECT "+questionColumns+" FROM questions WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Question not found: %w", err)
+	}
+
+	if err := s.loadAnswers(q); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// loadAnswers fills in the options, accepted answers and tags of q.
+func (s *sqlStore) loadAnswers(q *Question) error {
+	var err error
+	if q.Options, err = s.options(q.QID); err != nil {
+		return err
+	}
+
+	rows, err := s.db.Query(
+		"SELECT position, value, match_rule, tolerance FROM accepted_answers WHERE question_id = ? ORDER BY position",
+		q.QID,
+	)
+	if err != nil {
+		return fmt.Errorf("Failed to get accepted answers: %w", err)
+	}
+	defer rows.Close()
+
+	q.Accepted = nil
+	for rows.Next() {
+		var a AcceptedAnswer
+		if err := rows.Scan(&a.Position, &a.Value, &a.Match, &a.Tolerance); err != nil {
+			return fmt.Errorf("Failed to get accepted answers: %w", err)
+		}
+		q.Accepted = append(q.Accepted, a)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("Failed to get accepted answers: %w", err)
+	}
+	rows.Close()
+
+	tagRows, err := s.db.Query("SELECT tag FROM question_tags WHERE question_id = ? ORDER BY tag", q.QID)
+	if err != nil {
+		return fmt.Errorf("Failed to get tags: %w", err)
+	}
+	defer tagRows.Close()
+
+	q.Tags = nil
+	for tagRows.Next() {
+		var tag string
+		if err := tagRows.Scan(&tag); err != nil {
+			return fmt.Errorf("Failed to get tags: %w", err)
+		}
+		q.Tags = append(q.Tags, tag)
+	}
+
+	return tagRows.Err()
+}
+
+func (s *sqlStore) options(questionID int64) ([]QuestionOption, error) {
+	rows, err := s.db.Query(
+		"SELECT position, label, emoji, is_correct FROM question_options WHERE question_id = ? ORDER BY position",
+		questionID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get options: %w", err)
+	}
+	defer rows.Close()
+
+	var options []QuestionOption
+	for rows.Next() {
+		var opt QuestionOption
+		if err := rows.Scan(&opt.Position, &opt.Label, &opt.Emoji, &opt.IsCorrect); err != nil {
+			return nil, fmt.Errorf("Failed to get options: %w", err)
+		}
+		options = append(options, opt)
+	}
+
+	return options, rows.Err()
+}
+
+func (s *sqlStore) RecentQuestions(guildID int64, filter QuestionFilter, limit int) ([]*Question, error) {
+	where := "WHERE guild_id = ?"
+	args := []any{guildID}
+	if len(filter.Tags) > 0 {
+		where += " AND id IN (SELECT question_id FROM question_tags WHERE tag IN (?" + strings.Repeat(", ?", len(filter.Tags)-1) + "))"
+		for _, tag := range filter.Tags {
+			args = append(args, tag)
+		}
+	}
+	if !filter.Since.IsZero() {
+		where += " AND created_at >= ?"
+		args = append(args, filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		where += " AND created_at < ?"
+		args = append(args, filter.Until.UTC())
+	}
+	if filter.Closed != nil {
+		where += " AND is_closed = ?"
+		args = append(args, *filter.Closed)
+	}
+	if filter.CreatorID != 0 {
+		where += " AND creator_id = ?"
+		args = append(args, filter.CreatorID)
+	}
+	if filter.Anon != nil {
+		where += " AND is_anon = ?"
+		args = append(args, *filter.Anon)
+	}
+	order := " ORDER BY created_at ASC, id ASC"
+	if filter.NewestFirst {
+		order = " ORDER BY created_at DESC, id DESC"
+	}
+	query := "SELECT " + questionColumns + " FROM questions " + where + order
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get questions: %w", err)
+	}
+	defer rows.Close()
+
+	var questions []*Question
+	for rows.Next() {
+		q, err := scanQuestion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get questions: %w", err)
+		}
+		questions = append(questions, q)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for _, q := range questions {
+		if err := s.loadAnswers(q); err != nil {
+			return nil, err
+		}
+	}
+
+	return questions, nil
+}
+
+func (s *sqlStore) CloseQuestion(id int64, guildID int64) (bool, error) {
+	r, err := s.db.Exec("UPDATE questions SET is_closed = TRUE WHERE id = ? AND guild_id = ? AND is_closed = FALSE", id, guildID)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := r.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (s *sqlStore) UpdateQuestion(q *Question, edit *QuestionEdit) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to update question: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT label, emoji FROM question_options WHERE question_id = ? ORDER BY position", q.QID)
+	if err != nil {
+		return fmt.Errorf("failed to get options: %w", err)
+	}
+	var current []QuestionOption
+	for rows.Next() {
+		var opt QuestionOption
+		if err := rows.Scan(&opt.Label, &opt.Emoji); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to get options: %w", err)
+		}
+		current = append(current, opt)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to get options: %w", err)
+	}
+
+	if !sameOptions(current, q.Options) {
+		var answered bool
+		err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM response_events WHERE question_id = ?)", q.QID).Scan(&answered)
+		if err != nil {
+			return fmt.Errorf("failed to count responses: %w", err)
+		}
+		if answered {
+			return ErrHasResponses
+		}
+	}
+
+	r, err := tx.Exec("UPDATE questions SET question = ?, is_anon = ? WHERE id = ?", q.Question, q.IsAnon, q.QID)
+	if err != nil {
+		return fmt.Errorf("failed to update question: %w", err)
+	}
+	if n, err := r.RowsAffected(); err != nil || n != 1 {
+		return ErrNotFound
+	}
+
+	if _, err := tx.Exec("DELETE FROM question_options WHERE question_id = ?", q.QID); err != nil {
+		return fmt.Errorf("failed to update options: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM accepted_answers WHERE question_id = ?", q.QID); err != nil {
+		return fmt.Errorf("failed to update accepted answers: %w", err)
+	}
+	if err := insertAnswers(tx, q); err != nil {
+		return err
+	}
+
+	edit.QuestionID = q.QID
+	err = tx.QueryRow(
+		"INSERT INTO question_edits (question_id, editor_id, summary) VALUES (?, ?, ?) RETURNING id, edited_at",
+		edit.QuestionID,
+		edit.EditorID,
+		edit.Summary,
+	).Scan(&edit.ID, &edit.EditedAt)
+	if err != nil {
+		return fmt.Errorf("failed to log edit: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func (s *sqlStore) ScheduleClose(id int64, at *time.Time) error {
+	_, err := s.db.Exec("UPDATE questions SET close_at = ? WHERE id = ?", nullTime(at), id)
+	return err
+}
+
+func (s *sqlStore) DueQuestions(now time.Time) ([]*Question, error) {
+	rows, err := s.db.Query(
+		"SELECT "+questionColumns+" FROM questions WHERE is_closed = FALSE AND close_at <= ? ORDER BY close_at",
+		now.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due questions: %w", err)
+	}
+	defer rows.Close()
+
+	var questions []*Question
+	for rows.Next() {
+		q, err := scanQuestion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get due questions: %w", err)
+		}
+		questions = append(questions, q)
+	}
+
+	return questions, rows.Err()
+}
+
+func (s *sqlStore) CreateScheduledPost(p *ScheduledPost) error {
+	return s.db.QueryRow(
+		"INSERT INTO scheduled_posts (guild_id, channel_id, question_id, creator_id, post_at, close_after, attempts) VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id",
+		p.GuildID,
+		p.ChannelID,
+		p.QuestionID,
+		p.CreatorID,
+		p.PostAt.UTC(),
+		int64(p.CloseAfter/time.Second),
+		p.Attempts,
+	).Scan(&p.ID)
+}
+
+func (s *sqlStore) RequeueScheduledPost(p *ScheduledPost) error {
+	_, err := s.db.Exec(
+		"INSERT INTO scheduled_posts (id, guild_id, channel_id, question_id, creator_id, post_at, close_after, attempts) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		p.ID,
+		p.GuildID,
+		p.ChannelID,
+		p.QuestionID,
+		p.CreatorID,
+		p.PostAt.UTC(),
+		int64(p.CloseAfter/time.Second),
+		p.Attempts,
+	)
+	return err
+}
+
+func (s *sqlStore) queryScheduledPosts(where string, args ...any) ([]ScheduledPost, error) {
+	rows, err := s.db.Query(
+		"SELECT id, guild_id, channel_id, question_id, creator_id, post_at, close_after, attempts FROM scheduled_posts "+where+" ORDER BY post_at, id",
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled posts: %w", err)
+	}
+	defer rows.Close()
+
+	var posts []ScheduledPost
+	for rows.Next() {
+		var p ScheduledPost
+		var closeAfter int64
+		if err := rows.Scan(&p.ID, &p.GuildID, &p.ChannelID, &p.QuestionID, &p.CreatorID, &p.PostAt, &closeAfter, &p.Attempts); err != nil {
+			return nil, fmt.Errorf("failed to get scheduled posts: %w", err)
+		}
+		p.CloseAfter = time.Duration(closeAfter) * time.Second
+		posts = append(posts, p)
+	}
+
+	return posts, rows.Err()
+}
+
+func (s *sqlStore) ScheduledPosts(guildID int64) ([]ScheduledPost, error) {
+	return s.queryScheduledPosts("WHERE guild_id = ?", guildID)
+}
+
+func (s *sqlStore) DueScheduledPosts(now time.Time) ([]ScheduledPost, error) {
+	return s.queryScheduledPosts("WHERE post_at <= ?", now.UTC())
+}
+
+func (s *sqlStore) DeleteScheduledPost(id int64, guildID int64) (bool, error) {
+	r, err := s.db.Exec("DELETE FROM scheduled_posts WHERE id = ? AND guild_id = ?", id, guildID)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := r.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (s *sqlStore) CreateQuiz(quiz *Quiz) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to create quiz: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM quizzes WHERE guild_id = ? AND name = ?)", quiz.GuildID, quiz.Name).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to create quiz: %w", err)
+	}
+	if exists {
+		return ErrQuizExists
+	}
+
+	err = tx.QueryRow(
+		"INSERT INTO quizzes (guild_id, name, creator_id) VALUES (?, ?, ?) RETURNING id, created_at",
+		quiz.GuildID,
+		quiz.Name,
+		quiz.CreatorID,
+	).Scan(&quiz.ID, &quiz.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create quiz: %w", err)
+	}
+	quiz.QuestionIDs = nil
+
+	return tx.Commit()
+}
+
+func (s *sqlStore) queryQuizzes(where string, args ...any) ([]*Quiz, error) {
+	rows, err := s.db.Query("SELECT id, guild_id, name, creator_id, created_at FROM quizzes "+where+" ORDER BY name", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quizzes: %w", err)
+	}
+	defer rows.Close()
+
+	var quizzes []*Quiz
+	for rows.Next() {
+		quiz := &Quiz{}
+		if err := rows.Scan(&quiz.ID, &quiz.GuildID, &quiz.Name, &quiz.CreatorID, &quiz.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to get quizzes: %w", err)
+		}
+		quizzes = append(quizzes, quiz)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get quizzes: %w", err)
+	}
+
+	for _, quiz := range quizzes {
+		if quiz.QuestionIDs, err = s.quizQuestions(quiz.ID); err != nil {
+			return nil, err
+		}
+	}
+	return quizzes, nil
+}
+
+func (s *sqlStore) quizQuestions(quizID int64) ([]int64, error) {
+	rows, err := s.db.Query("SELECT question_id FROM quiz_questions WHERE quiz_id = ? ORDER BY position", quizID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quiz questions: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to get quiz questions: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (s *sqlStore) Quiz(guildID int64, name string) (*Quiz, error) {
+	quizzes, err := s.queryQuizzes("WHERE guild_id = ? AND name = ?", guildID, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(quizzes) == 0 {
+		return nil, ErrNotFound
+	}
+	return quizzes[0], nil
+}
+
+func (s *sqlStore) Quizzes(guildID int64) ([]*Quiz, error) {
+	return s.queryQuizzes("WHERE guild_id = ?", guildID)
+}
+
+func (s *sqlStore) AddQuizQuestions(quizID int64, questionIDs []int64) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to add quiz questions: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM quizzes WHERE id = ?)", quizID).Scan(&exists)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add quiz questions: %w", err)
+	}
+	if !exists {
+		return 0, ErrNotFound
+	}
+	for _, id := range questionIDs {
+		err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM questions WHERE id = ?)", id).Scan(&exists)
+		if err != nil {
+			return 0, fmt.Errorf("failed to add quiz questions: %w", err)
+		}
+		if !exists {
+			return 0, ErrNotFound
+		}
+	}
+
+	var next int
+	err = tx.QueryRow("SELECT COALESCE(MAX(position) + 1, 0) FROM quiz_questions WHERE quiz_id = ?", quizID).Scan(&next)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add quiz questions: %w", err)
+	}
+
+	added := 0
+	for _, id := range questionIDs {
+		var exists bool
+		err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM quiz_questions WHERE quiz_id = ? AND question_id = ?)", quizID, id).Scan(&exists)
+		if err != nil {
+			return 0, fmt.Errorf("failed to add quiz questions: %w", err)
+		}
+		if exists {
+			continue
+		}
+		_, err = tx.Exec("INSERT INTO quiz_questions (quiz_id, question_id, position) VALUES (?, ?, ?)", quizID, id, next)
+		if err != nil {
+			return 0, fmt.Errorf("failed to add quiz questions: %w", err)
+		}
+		next++
+		added++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to add quiz questions: %w", err)
+	}
+	return added, nil
+}
+
+func (s *sqlStore) GuildTimezone(guildID int64) (string, error) {
+	var timezone string
+	err := s.db.QueryRow("SELECT timezone FROM guild_settings WHERE guild_id = ?", guildID).Scan(&timezone)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "UTC", nil
+	}
+	return timezone, err
+}
+
+func (s *sqlStore) SetGuildTimezone(guildID int64, timezone string) error {
+	_, err := s.db.Exec(
+		"INSERT INTO guild_settings (guild_id, timezone) VALUES (?, ?) ON CONFLICT (guild_id) DO UPDATE SET timezone = excluded.timezone",
+		guildID,
+		timezone,
+	)
+	return err
+}
+
+func (s *sqlStore) RecordPost(p *QuestionPost) error {
+	return s.db.QueryRow(
+		"INSERT INTO question_posts (question_id, guild_id, channel_id, message_id, posted_by) VALUES (?, ?, ?, ?, ?) RETURNING posted_at",
+		p.QuestionID,
+		p.GuildID,
+		p.ChannelID,
+		p.MessageID,
+		p.PostedBy,
+	).Scan(&p.PostedAt)
+}
+
+func (s *sqlStore) Posts(questionID int64) ([]QuestionPost, error) {
+	rows, err := s.db.Query(
+		"SELECT question_id, guild_id, channel_id, message_id, posted_by, posted_at FROM question_posts WHERE question_id = ? ORDER BY posted_at",
+		questionID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get posts: %w", err)
+	}
+	defer rows.Close()
+
+	var posts []QuestionPost
+	for rows.Next() {
+		var p QuestionPost
+		var postedBy string
+		if err := rows.Scan(&p.QuestionID, &p.GuildID, &p.ChannelID, &p.MessageID, &postedBy, &p.PostedAt); err != nil {
+			return nil, fmt.Errorf("failed to get posts: %w", err)
+		}
+		// Posts from before posted_by was recorded have no poster
+		if p.PostedBy, err = strconv.ParseInt(postedBy, 10, 64); err != nil && postedBy != "" {
+			return nil, fmt.Errorf("failed to get posts: %w", err)
+		}
+		posts = append(posts, p)
+	}
+
+	return posts, rows.Err()
+}
+
+func (s *sqlStore) RecordResponse(questionID int64, userID int64, choice int) (*Response, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var multi bool
+	var kind string
+	err = tx.QueryRow("SELECT is_multi, kind FROM questions WHERE id = ? AND is_closed = FALSE", questionID).Scan(&multi, &kind)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrQuestionClosed
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	userEvents, err := queryResponseEvents(tx, "WHERE question_id = ? AND user_id = ?", questionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Multi-select clicks toggle the option
+	mode := questionFoldMode(kind, multi)
+	selected := true
+	if mode == foldMulti {
+		if current := foldResponses(userEvents, mode); len(current) == 1 {
+			selected = !current[0].hasChoice(choice)
+		}
+	}
+
+	ev := ResponseEvent{QuestionID: questionID, UserID: userID, Choice: choice, Selected: selected}
+	err = tx.QueryRow(
+		"INSERT INTO response_events (question_id, user_id, choice, selected) VALUES (?, ?, ?, ?) RETURNING id, responded_at",
+		questionID,
+		userID,
+		choice,
+		selected,
+	).Scan(&ev.ID, &ev.RespondedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	r := Response{QuestionID: questionID, UserID: userID}
+	if current := foldResponses(append(userEvents, ev), mode); len(current) == 1 {
+		r = current[0]
+	}
+	return &r, nil
+}
+
+func (s *sqlStore) RecordValue(questionID int64, userID int64, value string) (*Response, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRow("SELECT id FROM questions WHERE id = ? AND is_closed = FALSE", questionID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrQuestionClosed
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	userEvents, err := queryResponseEvents(tx, "WHERE question_id = ? AND user_id = ?", questionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ev := ResponseEvent{QuestionID: questionID, UserID: userID, Choice: noChoice, Selected: true, Value: value}
+	err = tx.QueryRow(
+		"INSERT INTO response_events (question_id, user_id, choice, value) VALUES (?, ?, ?, ?) RETURNING id, responded_at",
+		questionID,
+		userID,
+		noChoice,
+		value,
+	).Scan(&ev.ID, &ev.RespondedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	r := foldResponses(append(userEvents, ev), foldSingle)[0]
+	return &r, nil
+}
+
+// queryResponseEvents reads response events matching the where clause, oldest first.
+func queryResponseEvents(q interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}, where string, args ...any) ([]ResponseEvent, error) {
+	rows, err := q.Query(
+		"SELECT id, question_id, user_id, choice, selected, value, responded_at FROM response_events "+where+" ORDER BY id",
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get responses: %w", err)
+	}
+	defer rows.Close()
+
+	var events []ResponseEvent
+	for rows.Next() {
+		var ev ResponseEvent
+		if err := rows.Scan(&ev.ID, &ev.QuestionID, &ev.UserID, &ev.Choice, &ev.Selected, &ev.Value, &ev.RespondedAt); err != nil {
+			return nil, fmt.Errorf("failed to get responses: %w", err)
+		}
+		events = append(events, ev)
+	}
+
+	return events, rows.Err()
+}
+
+func (s *sqlStore) ResponseEvents(questionID int64) ([]ResponseEvent, error) {
+	return queryResponseEvents(s.db, "WHERE question_id = ?", questionID)
+}
+
+func (s *sqlStore) Responses(questionID int64) ([]Response, error) {
+	var multi bool
+	var kind string
+	err := s.db.QueryRow("SELECT is_multi, kind FROM questions WHERE id = ?", questionID).Scan(&multi, &kind)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.ResponseEvents(questionID)
+	if err != nil {
+		return nil, err
+	}
+	return foldResponses(events, questionFoldMode(kind, multi)), nil
+}
+
+func (s *sqlStore) CreateDraft(d *QuestionDraft) error {
+	payload, err := json.Marshal(d.Question)
+	if err != nil {
+		return fmt.Errorf("failed to encode draft: %w", err)
+	}
+
+	err = s.db.QueryRow(
+		"INSERT INTO drafts (creator_id, guild_id, channel_id, question, expires_at, close_in) VALUES (?, ?, ?, ?, ?, ?) RETURNING id",
+		d.CreatorID,
+		d.GuildID,
+		d.ChannelID,
+		string(payload),
+		d.ExpiresAt.UTC(),
+		int64(d.CloseIn/time.Second),
+	).Scan(&d.DraftID)
+	if err != nil {
+		return fmt.Errorf("failed to store draft: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStore) Draft(id int64) (*QuestionDraft, error) {
+	d := QuestionDraft{}
+	var payload string
+	var closeIn int64
+	err := s.db.QueryRow(
+		"SELECT id, channel_id, question, expires_at, close_in FROM drafts WHERE id = ? AND expires_at > ?",
+		id,
+		time.Now().UTC(),
+	).Scan(&d.DraftID, &d.ChannelID, &payload, &d.ExpiresAt, &closeIn)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get draft: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(payload), &d.Question); err != nil {
+		return nil, fmt.Errorf("failed to decode draft: %w", err)
+	}
+	d.CloseIn = time.Duration(closeIn) * time.Second
+
+	return &d, nil
+}
+
+func (s *sqlStore) ConfirmDraft(d *QuestionDraft) (*Question, error) {
+	q := d.Question
+	if q.Kind == "" {
+		q.Kind = KindChoice
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm draft: %w", err)
+	}
+	defer tx.Rollback()
+
+	r, err := tx.Exec("DELETE FROM drafts WHERE id = ? AND expires_at > ?", d.DraftID, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm draft: %w", err)
+	}
+	n, err := r.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm draft: %w", err)
+	}
+	if n == 0 {
+		return nil, ErrNotFound
+	}
+
+	if err := insertQuestion(tx, q); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to confirm draft: %w", err)
+	}
+
+	return q, nil
+}
+
+func (s *sqlStore) DeleteDraft(id int64) error {
+	_, err := s.db.Exec("DELETE FROM drafts WHERE id = ?", id)
+	return err
+}
+
+func (s *sqlStore) DeleteExpiredDrafts(now time.Time) error {
+	_, err := s.db.Exec("DELETE FROM drafts WHERE expires_at <= ?", now.UTC())
+	return err
+}
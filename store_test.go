@@ -0,0 +1,827 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// The store tests are a contract suite: every test runs against each QuestionStore,
+// so the stores cannot drift apart. PostgreSQL runs only when TEST_DATABASE_URL
+// points at a database the tests may wipe.
+
+// forEachStore runs test against a fresh, empty store of every kind.
+func forEachStore(t *testing.T, test func(t *testing.T, s QuestionStore)) {
+	t.Run("sqlite", func(t *testing.T) {
+		c := &Config{DBPath: filepath.Join(t.TempDir(), "qanda.db"), DBForeignKeys: true, DBWAL: true, DBBusyTimeout: 5 * time.Second}
+		s, err := openSQLStore(sqliteDialect, c.sqliteDSN())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { s.Close() })
+		test(t, s)
+	})
+	t.Run("memory", func(t *testing.T) {
+		test(t, newMemoryStore())
+	})
+	t.Run("postgres", func(t *testing.T) {
+		url := os.Getenv("TEST_DATABASE_URL")
+		if url == "" {
+			t.Skip("TEST_DATABASE_URL is not set")
+		}
+		resetPostgres(t, url)
+		s, err := openSQLStore(postgresDialect, url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { s.Close() })
+		test(t, s)
+	})
+}
+
+// resetPostgres drops everything in the public schema, so the migrations run from scratch.
+func resetPostgres(t *testing.T, url string) {
+	t.Helper()
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, query := range []string{`DROP SCHEMA public CASCADE`, `CREATE SCHEMA public`} {
+		if _, err := db.Exec(query); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// mustCreate stores q or fails the test.
+func mustCreate(t *testing.T, s QuestionStore, q *Question) *Question {
+	t.Helper()
+	q, err := s.CreateQuestion(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+func questionIDs(questions []*Question) []int64 {
+	ids := make([]int64, len(questions))
+	for i, q := range questions {
+		ids[i] = q.QID
+	}
+	return ids
+}
+
+func TestRebind(t *testing.T) {
+	query := "SELECT id FROM questions WHERE guild_id = ? AND id IN (?, ?)"
+	if got := sqliteDialect.rebind(query); got != query {
+		t.Errorf("sqlite rebind = %q", got)
+	}
+	want := "SELECT id FROM questions WHERE guild_id = $1 AND id IN ($2, $3)"
+	if got := postgresDialect.rebind(query); got != want {
+		t.Errorf("postgres rebind = %q, want %q", got, want)
+	}
+}
+
+func TestPostgresSchema(t *testing.T) {
+	schema := `CREATE TABLE t (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		question_id INTEGER NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+	want := `CREATE TABLE t (
+		id BIGSERIAL PRIMARY KEY,
+		question_id BIGINT NOT NULL,
+		created_at TIMESTAMP DEFAULT (now() AT TIME ZONE 'utc')
+	)`
+	if got := postgresDialect.schema.Replace(schema); got != want {
+		t.Errorf("postgres schema =\n%s\nwant\n%s", got, want)
+	}
+	if got := sqliteDialect.schema.Replace(schema); got != schema {
+		t.Errorf("sqlite schema =\n%s", got)
+	}
+}
+
+func TestColumnExists(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s QuestionStore) {
+		sq, ok := s.(*sqlStore)
+		if !ok {
+			t.Skip("not an SQL store")
+		}
+		tx, err := sq.db.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tx.Rollback()
+
+		for _, c := range []struct {
+			table, column string
+			want          bool
+		}{
+			{"questions", "is_anon", true},
+			{"drafts", "close_in", true},
+			{"scheduled_posts", "attempts", true},
+			{"questions", "no_such_column", false},
+			{"no_such_table", "id", false},
+		} {
+			got, err := columnExists(tx, c.table, c.column)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.want {
+				t.Errorf("columnExists(%s, %s) = %v, want %v", c.table, c.column, got, c.want)
+			}
+		}
+	})
+}
+
+func TestCreateQuestion(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s QuestionStore) {
+		source := mustCreate(t, s, &Question{CreatorID: 1, GuildID: 10, Question: "Source?"})
+		if source.QID == 0 {
+			t.Fatal("no ID returned")
+		}
+		if source.Kind != KindChoice {
+			t.Errorf("Kind = %q, want %q", source.Kind, KindChoice)
+		}
+		// The database fills in created_at in UTC
+		if d := time.Since(source.CreatedAt); d < -time.Minute || d > time.Minute {
+			t.Errorf("CreatedAt = %v, not now", source.CreatedAt)
+		}
+
+		closeAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		q := mustCreate(t, s, &Question{
+			CreatorID: 2,
+			GuildID:   10,
+			Question:  "Pick one",
+			IsAnon:    true,
+			IsSurvey:  true,
+			IsLive:    true,
+			CloseAt:   &closeAt,
+			SourceID:  source.QID,
+			Tags:      []string{"math", "easy"},
+			Options:   []QuestionOption{{Label: "A", Emoji: "🅰️"}, {Label: "B", IsCorrect: true}},
+		})
+		if q.QID == source.QID {
+			t.Fatal("IDs are not unique")
+		}
+
+		got, err := s.Question(q.QID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.CreatorID != 2 || got.GuildID != 10 || got.Question != "Pick one" || !got.IsAnon || !got.IsSurvey || !got.IsLive || got.IsClosed {
+			t.Errorf("got %+v", got)
+		}
+		if got.SourceID != source.QID {
+			t.Errorf("SourceID = %d, want %d", got.SourceID, source.QID)
+		}
+		if got.CloseAt == nil || !got.CloseAt.Equal(closeAt) {
+			t.Errorf("CloseAt = %v, want %v", got.CloseAt, closeAt)
+		}
+		if !slices.Equal(got.Tags, []string{"easy", "math"}) {
+			t.Errorf("Tags = %v", got.Tags)
+		}
+		want := []QuestionOption{{Position: 0, Label: "A", Emoji: "🅰️"}, {Position: 1, Label: "B", IsCorrect: true}}
+		if !slices.Equal(got.Options, want) {
+			t.Errorf("Options = %+v, want %+v", got.Options, want)
+		}
+
+		typed := mustCreate(t, s, &Question{
+			CreatorID: 2,
+			GuildID:   10,
+			Question:  "Capital of France?",
+			Kind:      KindText,
+			Accepted:  []AcceptedAnswer{{Value: "Paris", Match: MatchCI}, {Value: "^paris$", Match: MatchRegex}},
+		})
+		got, err = s.Question(typed.QID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantAccepted := []AcceptedAnswer{{Position: 0, Value: "Paris", Match: MatchCI}, {Position: 1, Value: "^paris$", Match: MatchRegex}}
+		if got.Kind != KindText || !slices.Equal(got.Accepted, wantAccepted) {
+			t.Errorf("got %s %+v, want %+v", got.Kind, got.Accepted, wantAccepted)
+		}
+
+		if _, err := s.Question(typed.QID + 100); !errors.Is(err, ErrNotFound) {
+			t.Errorf("missing question: err = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestRecentQuestions(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s QuestionStore) {
+		q1 := mustCreate(t, s, &Question{CreatorID: 1, GuildID: 10, Question: "One", Tags: []string{"math"}})
+		q2 := mustCreate(t, s, &Question{CreatorID: 2, GuildID: 10, Question: "Two", IsAnon: true, Tags: []string{"art"}})
+		q3 := mustCreate(t, s, &Question{CreatorID: 1, GuildID: 10, Question: "Three", Tags: []string{"math", "art"}})
+		mustCreate(t, s, &Question{CreatorID: 1, GuildID: 20, Question: "Elsewhere", Tags: []string{"math"}})
+		if _, err := s.CloseQuestion(q2.QID, 10); err != nil {
+			t.Fatal(err)
+		}
+
+		yes, no := true, false
+		for _, c := range []struct {
+			name   string
+			filter QuestionFilter
+			limit  int
+			want   []int64
+		}{
+			{"all", QuestionFilter{}, 0, []int64{q1.QID, q2.QID, q3.QID}},
+			{"newest first", QuestionFilter{NewestFirst: true}, 0, []int64{q3.QID, q2.QID, q1.QID}},
+			{"limit", QuestionFilter{NewestFirst: true}, 2, []int64{q3.QID, q2.QID}},
+			{"tags", QuestionFilter{Tags: []string{"math"}}, 0, []int64{q1.QID, q3.QID}},
+			{"any tag", QuestionFilter{Tags: []string{"math", "art"}}, 0, []int64{q1.QID, q2.QID, q3.QID}},
+			{"closed", QuestionFilter{Closed: &yes}, 0, []int64{q2.QID}},
+			{"open", QuestionFilter{Closed: &no}, 0, []int64{q1.QID, q3.QID}},
+			{"creator", QuestionFilter{CreatorID: 1}, 0, []int64{q1.QID, q3.QID}},
+			{"anon", QuestionFilter{Anon: &yes}, 0, []int64{q2.QID}},
+			{"since", QuestionFilter{Since: time.Now().Add(-time.Hour)}, 0, []int64{q1.QID, q2.QID, q3.QID}},
+			{"since later", QuestionFilter{Since: time.Now().Add(time.Hour)}, 0, nil},
+			{"until", QuestionFilter{Until: time.Now().Add(-time.Hour)}, 0, nil},
+			{"until later", QuestionFilter{Until: time.Now().Add(time.Hour), Tags: []string{"art"}, Closed: &no}, 0, []int64{q3.QID}},
+		} {
+			got, err := s.RecentQuestions(10, c.filter, c.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ids := questionIDs(got); !slices.Equal(ids, c.want) {
+				t.Errorf("%s: got %v, want %v", c.name, ids, c.want)
+			}
+		}
+	})
+}
+
+func TestCloseQuestion(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s QuestionStore) {
+		q := mustCreate(t, s, &Question{CreatorID: 1, GuildID: 10, Question: "Close me", Options: []QuestionOption{{Label: "A"}}})
+
+		if closed, err := s.CloseQuestion(q.QID, 20); err != nil || closed {
+			t.Errorf("closed from another guild: %v, %v", closed, err)
+		}
+		if closed, err := s.CloseQuestion(q.QID, 10); err != nil || !closed {
+			t.Errorf("close: %v, %v", closed, err)
+		}
+		if closed, err := s.CloseQuestion(q.QID, 10); err != nil || closed {
+			t.Errorf("closed twice: %v, %v", closed, err)
+		}
+		if _, err := s.RecordResponse(q.QID, 100, 0); !errors.Is(err, ErrQuestionClosed) {
+			t.Errorf("response to closed question: err = %v, want ErrQuestionClosed", err)
+		}
+		if _, err := s.RecordValue(q.QID, 100, "x"); !errors.Is(err, ErrQuestionClosed) {
+			t.Errorf("value for closed question: err = %v, want ErrQuestionClosed", err)
+		}
+	})
+}
+
+func TestScheduleClose(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s QuestionStore) {
+		soon := mustCreate(t, s, &Question{CreatorID: 1, GuildID: 10, Question: "Soon", Options: []QuestionOption{{Label: "A"}}})
+		later := mustCreate(t, s, &Question{CreatorID: 1, GuildID: 10, Question: "Later"})
+		closed := mustCreate(t, s, &Question{CreatorID: 1, GuildID: 10, Question: "Closed"})
+
+		now := time.Now().UTC().Truncate(time.Second)
+		past, future := now.Add(-time.Minute), now.Add(time.Hour)
+		for _, c := range []struct {
+			id int64
+			at *time.Time
+		}{{soon.QID, &past}, {later.QID, &future}, {closed.QID, &past}} {
+			if err := s.ScheduleClose(c.id, c.at); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := s.CloseQuestion(closed.QID, 10); err != nil {
+			t.Fatal(err)
+		}
+
+		due, err := s.DueQuestions(now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := questionIDs(due); !slices.Equal(ids, []int64{soon.QID}) {
+			t.Fatalf("due = %v, want [%d]", ids, soon.QID)
+		}
+		if due[0].GuildID != 10 || due[0].CloseAt == nil || !due[0].CloseAt.Equal(past) || len(due[0].Options) != 0 {
+			t.Errorf("due question = %+v", due[0])
+		}
+
+		if err := s.ScheduleClose(soon.QID, nil); err != nil {
+			t.Fatal(err)
+		}
+		if due, err := s.DueQuestions(now); err != nil || len(due) != 0 {
+			t.Errorf("due after clearing = %v, %v", questionIDs(due), err)
+		}
+		if got, err := s.Question(soon.QID); err != nil || got.CloseAt != nil {
+			t.Errorf("CloseAt after clearing = %v, %v", got.CloseAt, err)
+		}
+	})
+}
+
+func TestUpdateQuestion(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s QuestionStore) {
+		q := mustCreate(t, s, &Question{CreatorID: 1, GuildID: 10, Question: "Old", Tags: []string{"math"}, Options: []QuestionOption{{Label: "A"}, {Label: "B"}}})
+
+		q.Question = "New"
+		q.IsAnon = true
+		q.Options = []QuestionOption{{Label: "A"}, {Label: "C", IsCorrect: true}}
+		edit := &QuestionEdit{EditorID: 2, Summary: "text, options"}
+		if err := s.UpdateQuestion(q, edit); err != nil {
+			t.Fatal(err)
+		}
+		if edit.ID == 0 || edit.QuestionID != q.QID || edit.EditedAt.IsZero() {
+			t.Errorf("edit = %+v", edit)
+		}
+		got, err := s.Question(q.QID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []QuestionOption{{Position: 0, Label: "A"}, {Position: 1, Label: "C", IsCorrect: true}}
+		if got.Question != "New" || !got.IsAnon || !slices.Equal(got.Options, want) || !slices.Equal(got.Tags, []string{"math"}) {
+			t.Errorf("got %+v", got)
+		}
+
+		// Responses refer to the options by position, so only the rest can change now
+		if _, err := s.RecordResponse(q.QID, 100, 1); err != nil {
+			t.Fatal(err)
+		}
+		q.Options = []QuestionOption{{Label: "A"}}
+		if err := s.UpdateQuestion(q, &QuestionEdit{EditorID: 2}); !errors.Is(err, ErrHasResponses) {
+			t.Errorf("options changed with responses: err = %v, want ErrHasResponses", err)
+		}
+		q.Question = "Newer"
+		q.Options = got.Options
+		if err := s.UpdateQuestion(q, &QuestionEdit{EditorID: 2, Summary: "text"}); err != nil {
+			t.Errorf("text changed with responses: %v", err)
+		}
+		if got, err := s.Question(q.QID); err != nil || got.Question != "Newer" {
+			t.Errorf("got %v, %v", got, err)
+		}
+
+		missing := &Question{QID: q.QID + 100, Question: "Nothing"}
+		if err := s.UpdateQuestion(missing, &QuestionEdit{EditorID: 2}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("missing question: err = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestScheduledPosts(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s QuestionStore) {
+		q := mustCreate(t, s, &Question{CreatorID: 1, GuildID: 10, Question: "Later"})
+
+		now := time.Now().UTC().Truncate(time.Second)
+		later := &ScheduledPost{GuildID: 10, ChannelID: 30, QuestionID: q.QID, CreatorID: 1, PostAt: now.Add(time.Hour)}
+		due := &ScheduledPost{GuildID: 10, ChannelID: 30, QuestionID: q.QID, CreatorID: 1, PostAt: now.Add(-time.Minute), CloseAfter: 2 * time.Hour, Attempts: 2}
+		other := &ScheduledPost{GuildID: 20, ChannelID: 40, QuestionID: q.QID, CreatorID: 2, PostAt: now.Add(-time.Hour)}
+		for _, p := range []*ScheduledPost{later, due, other} {
+			if err := s.CreateScheduledPost(p); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if later.ID == 0 || later.ID == due.ID || due.ID == other.ID {
+			t.Fatalf("IDs = %d, %d, %d", later.ID, due.ID, other.ID)
+		}
+
+		posts, err := s.ScheduledPosts(10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(posts) != 2 || posts[0].ID != due.ID || posts[1].ID != later.ID {
+			t.Fatalf("guild posts = %+v", posts)
+		}
+		p := posts[0]
+		if p.GuildID != 10 || p.ChannelID != 30 || p.QuestionID != q.QID || p.CreatorID != 1 || !p.PostAt.Equal(due.PostAt) || p.CloseAfter != 2*time.Hour || p.Attempts != 2 {
+			t.Errorf("post = %+v", p)
+		}
+
+		posts, err = s.DueScheduledPosts(now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(posts) != 2 || posts[0].ID != other.ID || posts[1].ID != due.ID {
+			t.Errorf("due posts = %+v", posts)
+		}
+
+		if deleted, err := s.DeleteScheduledPost(due.ID, 20); err != nil || deleted {
+			t.Errorf("deleted from another guild: %v, %v", deleted, err)
+		}
+		if deleted, err := s.DeleteScheduledPost(due.ID, 10); err != nil || !deleted {
+			t.Errorf("delete: %v, %v", deleted, err)
+		}
+		if deleted, err := s.DeleteScheduledPost(due.ID, 10); err != nil || deleted {
+			t.Errorf("deleted twice: %v, %v", deleted, err)
+		}
+
+		// A retried post keeps its ID, so it can still be cancelled
+		due.Attempts = 3
+		if err := s.RequeueScheduledPost(due); err != nil {
+			t.Fatal(err)
+		}
+		posts, err = s.ScheduledPosts(10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(posts) != 2 || posts[0].ID != due.ID || posts[0].Attempts != 3 {
+			t.Errorf("requeued posts = %+v", posts)
+		}
+		next := &ScheduledPost{GuildID: 10, ChannelID: 30, QuestionID: q.QID, CreatorID: 1, PostAt: now}
+		if err := s.CreateScheduledPost(next); err != nil || next.ID == due.ID || next.ID == later.ID {
+			t.Errorf("post after requeue = %d, %v", next.ID, err)
+		}
+		if deleted, err := s.DeleteScheduledPost(due.ID, 10); err != nil || !deleted {
+			t.Errorf("delete requeued: %v, %v", deleted, err)
+		}
+	})
+}
+
+func TestQuizzes(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s QuestionStore) {
+		q1 := mustCreate(t, s, &Question{CreatorID: 1, GuildID: 10, Question: "One"})
+		q2 := mustCreate(t, s, &Question{CreatorID: 1, GuildID: 10, Question: "Two"})
+
+		week2 := &Quiz{GuildID: 10, Name: "Week 2", CreatorID: 1}
+		week1 := &Quiz{GuildID: 10, Name: "Week 1", CreatorID: 1}
+		for _, quiz := range []*Quiz{week2, week1, {GuildID: 20, Name: "Week 1", CreatorID: 2}} {
+			if err := s.CreateQuiz(quiz); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if week1.ID == 0 || week1.ID == week2.ID || week1.CreatedAt.IsZero() {
+			t.Errorf("quiz = %+v", week1)
+		}
+		if err := s.CreateQuiz(&Quiz{GuildID: 10, Name: "Week 1", CreatorID: 2}); !errors.Is(err, ErrQuizExists) {
+			t.Errorf("duplicate quiz: err = %v, want ErrQuizExists", err)
+		}
+
+		if added, err := s.AddQuizQuestions(week1.ID, []int64{q2.QID, q1.QID}); err != nil || added != 2 {
+			t.Errorf("add: %d, %v", added, err)
+		}
+		if added, err := s.AddQuizQuestions(week1.ID, []int64{q1.QID}); err != nil || added != 0 {
+			t.Errorf("add again: %d, %v", added, err)
+		}
+		if _, err := s.AddQuizQuestions(week2.ID, []int64{q1.QID, q2.QID + 100}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("missing question: err = %v, want ErrNotFound", err)
+		}
+		if _, err := s.AddQuizQuestions(week2.ID+100, []int64{q1.QID}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("missing quiz: err = %v, want ErrNotFound", err)
+		}
+
+		quiz, err := s.Quiz(10, "Week 1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if quiz.ID != week1.ID || quiz.CreatorID != 1 || !slices.Equal(quiz.QuestionIDs, []int64{q2.QID, q1.QID}) {
+			t.Errorf("quiz = %+v", quiz)
+		}
+		if _, err := s.Quiz(10, "Week 3"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("missing quiz: err = %v, want ErrNotFound", err)
+		}
+
+		quizzes, err := s.Quizzes(10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(quizzes) != 2 || quizzes[0].Name != "Week 1" || quizzes[1].Name != "Week 2" {
+			t.Fatalf("quizzes = %+v", quizzes)
+		}
+		// Nothing was added by the failed call
+		if len(quizzes[1].QuestionIDs) != 0 {
+			t.Errorf("Week 2 questions = %v", quizzes[1].QuestionIDs)
+		}
+	})
+}
+
+func TestGuildTimezone(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s QuestionStore) {
+		if tz, err := s.GuildTimezone(10); err != nil || tz != "UTC" {
+			t.Errorf("default = %q, %v", tz, err)
+		}
+		for _, tz := range []string{"Asia/Taipei", "Europe/Paris"} {
+			if err := s.SetGuildTimezone(10, tz); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := s.GuildTimezone(10); err != nil || got != tz {
+				t.Errorf("got %q, %v, want %q", got, err, tz)
+			}
+		}
+		if tz, err := s.GuildTimezone(20); err != nil || tz != "UTC" {
+			t.Errorf("other guild = %q, %v", tz, err)
+		}
+	})
+}
+
+func TestPosts(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s QuestionStore) {
+		q := mustCreate(t, s, &Question{CreatorID: 1, GuildID: 10, Question: "Posted"})
+
+		first := &QuestionPost{QuestionID: q.QID, GuildID: 10, ChannelID: 30, MessageID: 1000, PostedBy: 1}
+		second := &QuestionPost{QuestionID: q.QID, GuildID: 20, ChannelID: 40, MessageID: 2000, PostedBy: 2}
+		for _, p := range []*QuestionPost{first, second} {
+			if err := s.RecordPost(p); err != nil {
+				t.Fatal(err)
+			}
+			if p.PostedAt.IsZero() {
+				t.Error("PostedAt not filled in")
+			}
+		}
+
+		posts, err := s.Posts(q.QID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(posts) != 2 {
+			t.Fatalf("posts = %+v", posts)
+		}
+		// Posts made within the same second have no order
+		slices.SortFunc(posts, func(a, b QuestionPost) int { return int(a.MessageID - b.MessageID) })
+		for i, want := range []*QuestionPost{first, second} {
+			got := posts[i]
+			if got.QuestionID != want.QuestionID || got.GuildID != want.GuildID || got.ChannelID != want.ChannelID || got.MessageID != want.MessageID || got.PostedBy != want.PostedBy {
+				t.Errorf("post %d = %+v, want %+v", i, got, *want)
+			}
+		}
+
+		if posts, err := s.Posts(q.QID + 100); err != nil || len(posts) != 0 {
+			t.Errorf("posts of missing question = %v, %v", posts, err)
+		}
+	})
+}
+
+func TestResponses(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s QuestionStore) {
+		single := mustCreate(t, s, &Question{CreatorID: 1, GuildID: 10, Question: "Single", Options: []QuestionOption{{Label: "A"}, {Label: "B"}}})
+		for _, click := range []struct {
+			user   int64
+			choice int
+		}{{100, 0}, {101, 1}, {100, 1}} {
+			if _, err := s.RecordResponse(single.QID, click.user, click.choice); err != nil {
+				t.Fatal(err)
+			}
+		}
+		events, err := s.ResponseEvents(single.QID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 3 || events[0].ID >= events[1].ID || events[0].RespondedAt.IsZero() || events[2].UserID != 100 || events[2].Choice != 1 {
+			t.Errorf("events = %+v", events)
+		}
+		responses, err := s.Responses(single.QID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(responses) != 2 {
+			t.Fatalf("responses = %+v", responses)
+		}
+		for _, r := range responses {
+			if r.UserID == 100 && (!slices.Equal(r.Choices, []int{1}) || !slices.Equal(r.FirstChoices, []int{0}) || r.Changes != 1) {
+				t.Errorf("changed response = %+v", r)
+			}
+		}
+
+		multi := mustCreate(t, s, &Question{CreatorID: 1, GuildID: 10, Question: "Multi", IsMulti: true, Options: []QuestionOption{{Label: "A"}, {Label: "B"}}})
+		for _, choice := range []int{0, 1, 0} {
+			if _, err := s.RecordResponse(multi.QID, 100, choice); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// The second click on A takes it back out
+		r, err := s.RecordResponse(multi.QID, 101, 1)
+		if err != nil || !slices.Equal(r.Choices, []int{1}) {
+			t.Errorf("multi response = %+v, %v", r, err)
+		}
+		responses, err = s.Responses(multi.QID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(responses) != 2 || !slices.Equal(responses[0].Choices, []int{1}) || !slices.Equal(responses[0].FirstChoices, []int{0, 1}) {
+			t.Errorf("multi responses = %+v", responses)
+		}
+
+		rank := mustCreate(t, s, &Question{CreatorID: 1, GuildID: 10, Question: "Rank", Kind: KindRank, Options: []QuestionOption{{Label: "A"}, {Label: "B"}}})
+		for _, choice := range []int{1, noChoice, 0, 1} {
+			if r, err = s.RecordResponse(rank.QID, 100, choice); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if !slices.Equal(r.Choices, []int{0, 1}) || !slices.Equal(r.FirstChoices, []int{1}) {
+			t.Errorf("rank response = %+v", r)
+		}
+
+		typed := mustCreate(t, s, &Question{CreatorID: 1, GuildID: 10, Question: "Typed", Kind: KindText})
+		if _, err := s.RecordValue(typed.QID, 100, "paris"); err != nil {
+			t.Fatal(err)
+		}
+		r, err = s.RecordValue(typed.QID, 100, "Paris")
+		if err != nil || r.Value != "Paris" || r.FirstValue != "paris" || r.Changes != 1 {
+			t.Errorf("typed response = %+v, %v", r, err)
+		}
+
+		missing := typed.QID + 100
+		if _, err := s.RecordResponse(missing, 100, 0); !errors.Is(err, ErrQuestionClosed) {
+			t.Errorf("response to missing question: err = %v, want ErrQuestionClosed", err)
+		}
+		if _, err := s.Responses(missing); !errors.Is(err, ErrNotFound) {
+			t.Errorf("responses of missing question: err = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestConcurrentResponses(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s QuestionStore) {
+		q := mustCreate(t, s, &Question{CreatorID: 1, GuildID: 10, Question: "Busy", IsMulti: true, Options: []QuestionOption{{Label: "A"}, {Label: "B"}}})
+
+		// Every click reads the user's answer before writing, like a crowd clicking at once
+		const users = 50
+		var wg sync.WaitGroup
+		errs := make(chan error, users*2)
+		for u := range users {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := s.RecordResponse(q.QID, int64(100+u), u%2); err != nil {
+					errs <- err
+				}
+				if _, err := s.RecordValue(q.QID, int64(100+u), "x"); err != nil {
+					errs <- err
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Error(err)
+		}
+
+		events, err := s.ResponseEvents(q.QID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != users*2 {
+			t.Errorf("recorded %d events, want %d", len(events), users*2)
+		}
+	})
+}
+
+func TestDrafts(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s QuestionStore) {
+		newDraft := func(expiresAt time.Time) *QuestionDraft {
+			d := &QuestionDraft{
+				Question: &Question{
+					CreatorID: 1,
+					GuildID:   10,
+					Question:  "Drafted",
+					Tags:      []string{"math"},
+					Options:   []QuestionOption{{Label: "A", IsCorrect: true}},
+				},
+				ChannelID: 30,
+				ExpiresAt: expiresAt,
+				CloseIn:   2 * time.Hour,
+			}
+			if err := s.CreateDraft(d); err != nil {
+				t.Fatal(err)
+			}
+			return d
+		}
+		now := time.Now().UTC().Truncate(time.Second)
+		d := newDraft(now.Add(time.Hour))
+		expired := newDraft(now.Add(-time.Minute))
+		if d.DraftID == 0 || d.DraftID == expired.DraftID {
+			t.Fatalf("IDs = %d, %d", d.DraftID, expired.DraftID)
+		}
+
+		got, err := s.Draft(d.DraftID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.ChannelID != 30 || !got.ExpiresAt.Equal(d.ExpiresAt) || got.CloseIn != 2*time.Hour || got.CreatorID != 1 || got.Question.Question != "Drafted" || len(got.Options) != 1 {
+			t.Errorf("draft = %+v, question %+v", got, got.Question)
+		}
+		if _, err := s.Draft(expired.DraftID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expired draft: err = %v, want ErrNotFound", err)
+		}
+		if _, err := s.ConfirmDraft(expired); !errors.Is(err, ErrNotFound) {
+			t.Errorf("confirmed expired draft: err = %v, want ErrNotFound", err)
+		}
+
+		q, err := s.ConfirmDraft(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored, err := s.Question(q.QID); err != nil || stored.Question != "Drafted" || !slices.Equal(stored.Tags, []string{"math"}) || len(stored.Options) != 1 {
+			t.Errorf("confirmed question = %+v, %v", stored, err)
+		}
+		if _, err := s.ConfirmDraft(got); !errors.Is(err, ErrNotFound) {
+			t.Errorf("confirmed twice: err = %v, want ErrNotFound", err)
+		}
+		if _, err := s.Draft(d.DraftID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("draft after confirming: err = %v, want ErrNotFound", err)
+		}
+
+		cancelled := newDraft(now.Add(time.Hour))
+		if err := s.DeleteDraft(cancelled.DraftID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Draft(cancelled.DraftID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("deleted draft: err = %v, want ErrNotFound", err)
+		}
+
+		kept := newDraft(now.Add(time.Hour))
+		if err := s.DeleteExpiredDrafts(now); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Draft(kept.DraftID); err != nil {
+			t.Errorf("unexpired draft: %v", err)
+		}
+	})
+}
+
+func TestFoldResponses(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	type click struct {
+		user     int64
+		choice   int
+		selected bool
+		value    string
+	}
+	type want struct {
+		user       int64
+		choices    []int
+		first      []int
+		value      string
+		firstValue string
+		changes    int
+	}
+	for _, c := range []struct {
+		name   string
+		mode   foldMode
+		clicks []click
+		want   []want
+	}{
+		{"single", foldSingle, []click{
+			{1, 0, true, ""},
+			{2, 1, true, ""},
+			{1, 1, true, ""},
+		}, []want{
+			{2, []int{1}, []int{1}, "", "", 0},
+			{1, []int{1}, []int{0}, "", "", 1},
+		}},
+		{"typed", foldSingle, []click{
+			{1, noChoice, true, "paris"},
+			{1, noChoice, true, "Paris"},
+		}, []want{
+			{1, nil, nil, "Paris", "paris", 1},
+		}},
+		{"multi", foldMulti, []click{
+			{1, 1, true, ""},
+			{1, 0, true, ""},
+			{2, 0, true, ""},
+			{1, 1, false, ""},
+			{2, 0, false, ""},
+			{1, 2, true, ""},
+		}, []want{
+			{1, []int{0, 2}, []int{0, 1}, "", "", 1},
+		}},
+		{"rank", foldRank, []click{
+			{1, 2, true, ""},
+			{2, 1, true, ""},
+			{1, 0, true, ""},
+			{2, 1, true, ""},
+			{3, 0, true, ""},
+			{2, noChoice, true, ""},
+			{3, noChoice, true, ""},
+			{1, 1, true, ""},
+			{2, 0, true, ""},
+			{2, 1, true, ""},
+		}, []want{
+			{1, []int{2, 0, 1}, []int{2, 0, 1}, "", "", 0},
+			{2, []int{0, 1}, []int{1}, "", "", 1},
+		}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			var events []ResponseEvent
+			for i, cl := range c.clicks {
+				events = append(events, ResponseEvent{
+					QuestionID:  7,
+					UserID:      cl.user,
+					Choice:      cl.choice,
+					Selected:    cl.selected,
+					Value:       cl.value,
+					RespondedAt: start.Add(time.Duration(i) * time.Minute),
+				})
+			}
+			got := foldResponses(events, c.mode)
+			if len(got) != len(c.want) {
+				t.Fatalf("responses = %+v", got)
+			}
+			for i, w := range c.want {
+				r := got[i]
+				if r.QuestionID != 7 || r.UserID != w.user || !slices.Equal(r.Choices, w.choices) || !slices.Equal(r.FirstChoices, w.first) || r.Value != w.value || r.FirstValue != w.firstValue || r.Changes != w.changes {
+					t.Errorf("response %d = %+v, want %+v", i, r, w)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// parseTags reads a comma-separated tag list such as "Networking, #linux". Tags are
+// lowercased and kept once each, sorted the way the store returns them.
+func parseTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		tag = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(tag), "#")))
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	slices.Sort(tags)
+	return tags
+}
+
+// formatTags shows the tags as a line of hashtags.
+func formatTags(tags []string) string {
+	formatted := make([]string, len(tags))
+	for i, tag := range tags {
+		formatted[i] = "`#" + tag + "`"
+	}
+	return "🏷️ " + strings.Join(formatted, " ")
+}